@@ -0,0 +1,90 @@
+package lgr
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// the environment variables read by init, each overrides the matching default
+//  LGR_LEVEL         log threshold, ie. DEBUG, in place of DefaultLogThreshold
+//  LGR_STDOUT_LEVEL  stdout threshold, in place of DefaultStdoutThreshold
+//  LGR_FLAGS         log flags, ie. date,time,shortfile, in place of DefaultFlags
+//  LGR_FILE          path of the log file, FileHandle, see SetLogFile
+//  LGR_COLOR         auto, always or never
+const (
+	EnvLevel		= "LGR_LEVEL"
+	EnvStdoutLevel	= "LGR_STDOUT_LEVEL"
+	EnvFlags		= "LGR_FLAGS"
+	EnvFile			= "LGR_FILE"
+	EnvColor		= "LGR_COLOR"
+)
+
+// flagNames are the names of the log flag constants accepted in LGR_FLAGS
+var flagNames = map[string]int{
+	"date":         log.Ldate,
+	"time":         log.Ltime,
+	"microseconds": log.Lmicroseconds,
+	"longfile":     log.Llongfile,
+	"shortfile":    log.Lshortfile,
+	"utc":          log.LUTC,
+	"stdflags":     log.LstdFlags,
+}
+
+// parseFlags combines the named log flags, see flagNames
+func parseFlags(names []string) (int, error) {
+	var flags int
+	for _, name := range names {
+		flag, ok := flagNames[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return 0, fmt.Errorf("unknown flag %q, expected date, time, microseconds, longfile, shortfile, utc or stdflags", name)
+		}
+		flags |= flag
+	}
+	return flags, nil
+}
+
+// loadEnvironment applies the LGR_ environment variables,
+// an invalid value is warned about and the default is kept
+func loadEnvironment() {
+	if value, ok := os.LookupEnv(EnvColor); ok {
+		switch strings.ToLower(strings.TrimSpace(value)) {
+			case "", "auto":
+			case "always", "true", "1":
+				color.NoColor = false
+			case "never", "false", "0":
+				color.NoColor = true
+			default:
+				WARN.Printf("%s ignored, keeping auto: unknown value %q, expected auto, always or never", EnvColor, value)
+		}
+	}
+	if value, ok := os.LookupEnv(EnvFlags); ok {
+		if flags, err := parseFlags(strings.Split(value, ",")); err != nil {
+			WARN.Printf("%s ignored, keeping the default flags: %s", EnvFlags, err)
+		} else {
+			SetLogFlags(flags)
+		}
+	}
+	if value, ok := os.LookupEnv(EnvLevel); ok {
+		if level, err := ParseLevel(value); err != nil {
+			WARN.Printf("%s ignored, keeping %s: %s", EnvLevel, LevelToString(LogThreshold()), err)
+		} else {
+			SetLogThreshold(level)
+		}
+	}
+	if value, ok := os.LookupEnv(EnvStdoutLevel); ok {
+		if level, err := ParseLevel(value); err != nil {
+			WARN.Printf("%s ignored, keeping %s: %s", EnvStdoutLevel, LevelToString(StdoutThreshold()), err)
+		} else {
+			SetStdoutThreshold(level)
+		}
+	}
+	if path := os.Getenv(EnvFile); path != "" {
+		if err := SetLogFile(path); err != nil {
+			WARN.Printf("%s ignored: %s", EnvFile, err)
+		}
+	}
+}
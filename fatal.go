@@ -0,0 +1,105 @@
+package lgr
+
+import (
+	"io"
+	"os"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// hooksMu guards exitCode and the hooks, apart from configMu, which may be held while a line is written
+	hooksMu         sync.Mutex
+	exitCode        int = 1
+	fatalHooks      []func()
+	criticalHooks   []func(line string)
+	// exiting is set by the first FATAL line, later ones do not run the hooks again
+	exiting         int32
+)
+
+// SetExitCode sets the code the process exits with after logging to FATAL, 1 unless set
+func SetExitCode(code int) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	exitCode = code
+}
+
+// OnFatal registers hook to be run when a line is logged to FATAL,
+// once the log file has been synced and before the process exits, see SetExitCode
+// the hooks are run in the order they were registered, ie. to close connections or remove a pid file
+// a hook must not log to FATAL itself, the FATAL logger is still busy with the line
+func OnFatal(hook func()) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	fatalHooks = append(fatalHooks, hook)
+}
+
+// OnCritical registers hook to be run with each line logged to CRITICAL, once it has been written,
+// ie. to page someone or to DumpGoroutines, the process keeps running
+// a hook must not log to CRITICAL itself, the CRITICAL logger is still busy with the line
+func OnCritical(hook func(line string)) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	criticalHooks = append(criticalHooks, hook)
+}
+
+// DumpGoroutines returns a hook for OnCritical which writes the stack of every goroutine to writer, ie. os.Stderr
+func DumpGoroutines(writer io.Writer) func(line string) {
+	return func(line string) {
+		buf := make([]byte, 64<<10)
+		for {
+			n := runtime.Stack(buf, true)
+			if n < len(buf) {
+				writer.Write(buf[:n])
+				return
+			}
+			buf = make([]byte, 2*len(buf))
+		}
+	}
+}
+
+// hookWriter is the Handle of CRITICAL and FATAL, see refreshLogTypes,
+// it runs their hooks once each line has been written to handle
+// file is the FileHandle of the time, to be synced before exiting
+type hookWriter struct {
+	level   Level
+	handle  io.Writer
+	file    io.Writer
+}
+
+func (hw hookWriter) Write(p []byte) (n int, err error) {
+	n, err = hw.handle.Write(p)
+	switch {
+		case hw.level == LevelCritical:
+			hooksMu.Lock()
+			hooks := criticalHooks
+			hooksMu.Unlock()
+			for _, hook := range hooks {
+				runHook(func() { hook(string(p)) })
+			}
+		case hw.level >= LevelFatal:
+			if !atomic.CompareAndSwapInt32(&exiting, 0, 1) {
+				return n, err
+			}
+			if file, ok := hw.file.(*os.File); ok {
+				file.Sync()
+			}
+			hooksMu.Lock()
+			hooks, code := fatalHooks, exitCode
+			hooksMu.Unlock()
+			for _, hook := range hooks {
+				runHook(hook)
+			}
+			os.Exit(code)
+	}
+	return n, err
+}
+
+// runHook runs hook, a panic within it is recovered so that the remaining hooks, and the exit, still happen
+func runHook(hook func()) {
+	defer func() {
+		recover()
+	}()
+	hook()
+}
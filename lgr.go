@@ -3,15 +3,20 @@
 package lgr
 
 import (
+	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
 	"os"
+	"os/signal"
+	"runtime/debug"
+	"syscall"
 
 	"github.com/fatih/color"
 )
 
 import "strings"
+import "sync"
 
 // Level describes the chosen log level between
 // debug and critical.
@@ -26,8 +31,21 @@ type LogType struct {
     color           *color.Color
 	PrintDebug      bool
     Flags           int
+    // Fields are added to every line, see With
+    Fields          Fields
+    // parent is the LogType this one was derived from by With
+    parent          *LogType
 }
 
+// Field is a single key/value pair attached to a LogType with With
+type Field struct {
+    Key     string
+    Value   interface{}
+}
+
+// Fields is the list of key/value pairs which are added to every line
+type Fields []Field
+
 const (
     // LevelTrace Excessive User Output
 	LevelTrace Level = iota
@@ -142,21 +160,85 @@ var (
 
     // FileHandle is the handle for the log file to write to
 	FileHandle      io.Writer  = ioutil.Discard
+    // logFilePath is the path FileHandle was opened at by SetLogFile or UseTempLogFile, for Reopen
+    logFilePath     string
 
     LogTypes        []*LogType = []*LogType{Trace, Debug, Info, Msg, Warn, Error, Critical, Fatal}
+
+    // configMu guards the thresholds, FileHandle and the settings of the LogTypes
+    // so that they can be changed while other goroutines are logging
+    configMu        sync.RWMutex
+    // consoleMu serializes writes to the console, so that the color sequences of concurrent lines never interleave
+    consoleMu       sync.Mutex
+
+    // repanic is whether Recover panics again once the panic is logged, see SetRepanic
+    repanic         bool       = true
 )
 
-func (lt LogType) Write(p []byte) (n int, err error) {
+func (lt *LogType) Write(p []byte) (n int, err error) {
     var str string = string(p[:])
     var strs []string = strings.SplitN(str,":",6)
     var msg string = str
     if len(strs) >= 6 {
         msg = strs[5]
     } 
-    if !lt.PrintDebug { 
-        lt.color.Print(msg)
-    } else {
-        lt.color.Print(str)
+    if lt.PrintDebug { 
+        msg = str
+    }
+    // the colored line is written at once, color.Print would write the sequences and the message separately
+    consoleMu.Lock()
+    defer consoleMu.Unlock()
+    fmt.Fprint(color.Output, lt.color.Sprint(msg))
+    return len(p), nil
+}
+
+
+// String renders the fields as ` key=value` pairs, ready to be appended to a message
+func (fields Fields) String() string {
+    var str string
+    for _, field := range fields {
+        str += fmt.Sprintf(" %s=%v", field.Key, field.Value)
+    }
+    return str
+}
+
+// With returns a copy of the LogType whose Logger adds the key/value pairs
+// to every line it emits, ie. Error.With("request", id, "user", user).Logger
+// the copy keeps writing to the Handle of the LogType it came from
+func (lt *LogType) With(keyvals ...interface{}) *LogType {
+    configMu.RLock()
+    derived := *lt
+    configMu.RUnlock()
+    if lt.parent == nil {
+        derived.parent = lt
+    }
+    derived.Fields = make(Fields, len(lt.Fields), len(lt.Fields)+len(keyvals)/2+1)
+    copy(derived.Fields, lt.Fields)
+    for i := 0; i < len(keyvals); i += 2 {
+        field := Field{Key: fmt.Sprint(keyvals[i]), Value: "!MISSING"}
+        if i+1 < len(keyvals) {
+            field.Value = keyvals[i+1]
+        }
+        derived.Fields = append(derived.Fields, field)
+    }
+    logger := log.New(fieldWriter{&derived}, derived.Prefix, derived.Flags)
+    derived.Logger = &logger
+    return &derived
+}
+
+// fieldWriter appends the fields of a derived LogType to each line
+// before passing it on to the Handle of its parent
+type fieldWriter struct {
+    lt *LogType
+}
+
+func (fw fieldWriter) Write(p []byte) (n int, err error) {
+    line := strings.TrimSuffix(string(p), "\n") + fw.lt.Fields.String() + "\n"
+    // held while writing, so that the log file is not closed by SetLogFile or Reopen meanwhile
+    configMu.RLock()
+    defer configMu.RUnlock()
+    if _, err = io.WriteString(fw.lt.parent.Handle, line); err != nil {
+        return 0, err
     }
     return len(p), nil
 }
@@ -166,13 +248,17 @@ func (lt LogType) Write(p []byte) (n int, err error) {
 // some feedback and logging a potentially different amount based on independent log and output thresholds.
 // By default the output has a lower threshold than logged
 // Don't use if you have manually set the Handles of the different levels as it will overwrite them.
+// the defaults are then overridden by the LGR_ environment variables, see EnvLevel
 func init() {
 	SetStdoutThreshold(DefaultStdoutThreshold)
     SetLogThreshold(DefaultStdoutThreshold)
-    
+    loadEnvironment()
 }
 
 
+// refreshLogTypes points each LogType at the outputs its level reaches, the caller must hold configMu
+// the log.Logger of each LogType is created once and then changed in place,
+// so that TRACE, ERROR, etc. can be used from other goroutines meanwhile
 func refreshLogTypes(){
 	// see log flag constants
 	// https://golang.org/pkg/log/#pkg-constants
@@ -194,8 +280,18 @@ func refreshLogTypes(){
             // log to FileLogger only
 			n.Handle = FileHandle
 		}
+        // CRITICAL and FATAL run their hooks, and FATAL exits, once the line is written, see OnFatal
+        if n.Level >= LevelCritical && n.Handle != ioutil.Discard {
+            n.Handle = hookWriter{level: n.Level, handle: n.Handle, file: FileHandle}
+        }
 
-        *n.Logger = log.New(n.Handle, n.Prefix, n.Flags)
+        if *n.Logger == nil {
+            *n.Logger = log.New(n.Handle, n.Prefix, n.Flags)
+        } else {
+            (*n.Logger).SetOutput(n.Handle)
+            (*n.Logger).SetPrefix(n.Prefix)
+            (*n.Logger).SetFlags(n.Flags)
+        }
 
 	}
 
@@ -204,12 +300,16 @@ func refreshLogTypes(){
 // LogThreshold returns the current global log threshold.
 // Level is the current Log Level ( file output level )
 func LogThreshold() Level {
+	configMu.RLock()
+	defer configMu.RUnlock()
 	return logThreshold
 }
 
 // StdoutThreshold returns the current global output threshold.
 // Level is the current Stdout ( terminal output level )
 func StdoutThreshold() Level {
+	configMu.RLock()
+	defer configMu.RUnlock()
 	return outputThreshold
 }
 
@@ -227,88 +327,234 @@ func levelCheck(level Level) Level {
 
 // SetLogFlags runs log.SetFlags on all of the log handles contained within LogTypes
 func SetLogFlags(flags int) {
+	configMu.Lock()
 	for _, n := range LogTypes {
         n.Flags = flags
     }
 	refreshLogTypes()
+	configMu.Unlock()
     INFO.Printf("DefaultFlags(%+v)",flags)
 }
 
 // SetLogThreshold Establishes a threshold where anything matching or above will be logged
 func SetLogThreshold(level Level) {
+	configMu.Lock()
 	logThreshold = levelCheck(level)
 	refreshLogTypes()
-    INFO.Printf("SetLogThreshold(%+v/%+v)",level,logThreshold)
+	configMu.Unlock()
+    INFO.Printf("SetLogThreshold(%+v/%+v)",level,levelCheck(level))
 }
 
 // SetStdoutThreshold Establishes a threshold where anything matching or above will be output
 func SetStdoutThreshold(level Level) {
+	configMu.Lock()
 	outputThreshold = levelCheck(level)
 	refreshLogTypes()
-    INFO.Printf("SetStdoutThreshold(%+v/%+v)",level,outputThreshold)
+	configMu.Unlock()
+    INFO.Printf("SetStdoutThreshold(%+v/%+v)",level,levelCheck(level))
 }
 
 // SetLogFile Sets the Log Handle to an io.writer
 // takes a single string argument of `path` which is the path to be used as the log file
 // This file will be appended to or created
-func SetLogFile(path string) {
+// when it cannot be, the error is returned and the Log Handle is left as it was
+func SetLogFile(path string) error {
 	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0666)
 	if err != nil {
-		CRITICAL.Println("Failed to open log file:", path, err)
-		os.Exit(-1)
+		return fmt.Errorf("Failed to open log file:%s\n%s", path, err)
 	}
 
 	INFO.Println("Logging to", file.Name())
 
-	FileHandle = file
-	refreshLogTypes()
+	useLogFile(file, path)
+	return nil
 }
 
 // UseTempLogFile Creates a temporary file and sets the Log Handle to a io.writer created for it
-func UseTempLogFile(prefix string) {
+// when it cannot be created, the error is returned and the Log Handle is left as it was
+func UseTempLogFile(prefix string) error {
 	file, err := ioutil.TempFile(os.TempDir(), prefix)
 	if err != nil {
-		CRITICAL.Println(err)
+		return fmt.Errorf("Failed to open temporary log file:%s\n%s", prefix, err)
 	}
 
 	INFO.Println("Logging to", file.Name())
 
-	FileHandle = file
+	useLogFile(file, file.Name())
+	return nil
+}
+
+// SetLogFileFallback uses the first of paths which can be opened as the log file, see SetLogFile
+// ie. SetLogFileFallback("/var/log/app.log", filepath.Join(os.TempDir(), "app.log"))
+// when none of them can be, the log is written to stderr instead, and an error listing why each failed is returned
+func SetLogFileFallback(paths ...string) error {
+	var failures []string
+	for _, path := range paths {
+		if err := SetLogFile(path); err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+		return nil
+	}
+
+	useLogFile(os.Stderr, "")
+	return fmt.Errorf("Failed to open any log file, writing to stderr:%s\n%s", strings.Join(paths, ", "), strings.Join(failures, "\n"))
+}
+
+// Reopen closes the log file and opens it again at the same path
+// ie. after logrotate has moved it away, otherwise lgr would keep writing to the moved file
+func Reopen() error {
+	configMu.Lock()
+	path := logFilePath
+	if path == "" {
+		configMu.Unlock()
+		return nil
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0666)
+	if err != nil {
+		configMu.Unlock()
+		return err
+	}
+	old := swapLogFile(file, path)
+	configMu.Unlock()
+	if old != nil {
+		old.Close()
+	}
+	INFO.Println("Reopened", path)
+	return nil
+}
+
+// useLogFile points the LogTypes at handle, opened at path, or at no path for stderr or ioutil.Discard,
+// then closes the log file it replaces, see swapLogFile
+func useLogFile(handle io.Writer, path string) {
+	configMu.Lock()
+	old := swapLogFile(handle, path)
+	configMu.Unlock()
+	if old != nil {
+		old.Close()
+	}
+}
+
+// swapLogFile is useLogFile for a caller holding configMu, the replaced log file is returned for the caller to close
+// once configMu is released, or nil when it was not opened by lgr
+// the loggers no longer write to the old file once refreshLogTypes has returned, so it can be closed
+func swapLogFile(handle io.Writer, path string) io.Closer {
+	old, oldPath := FileHandle, logFilePath
+	FileHandle = handle
+	logFilePath = path
 	refreshLogTypes()
+	if closer, ok := old.(io.Closer); ok && oldPath != "" && old != handle {
+		return closer
+	}
+	return nil
+}
+
+// ReopenOnSignal calls Reopen whenever one of signals, SIGHUP when none are given, is received
+// stop ends this, errors are logged to ERROR
+func ReopenOnSignal(signals ...os.Signal) (stop func()) {
+    if len(signals) == 0 {
+        signals = []os.Signal{syscall.SIGHUP}
+    }
+    received := make(chan os.Signal, 1)
+    done := make(chan struct{})
+    signal.Notify(received, signals...)
+    go func() {
+        for {
+            select {
+            case <-received:
+                if err := Reopen(); err != nil {
+                    ERROR.Println("Failed to reopen log file:", err)
+                }
+            case <-done:
+                return
+            }
+        }
+    }()
+    return func() {
+        signal.Stop(received)
+        close(done)
+    }
+}
+
+// SetRepanic chooses whether Recover, and so Go, panics again with the value once it has been logged,
+// true unless set, false swallows the panic
+func SetRepanic(again bool) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	repanic = again
+}
+
+// Recover logs a panic, with its stack, to CRITICAL, syncs the log file, then panics again or returns, see SetRepanic
+// it must be deferred directly, ie. defer lgr.Recover()
+func Recover() {
+	if value := recover(); value != nil {
+		recovered(value)
+	}
+}
+
+// Go runs f in a new goroutine, where a panic is logged by Recover
+// otherwise a panic in a goroutine only reaches stderr, never the log file
+func Go(f func()) {
+	go func() {
+		defer Recover()
+		f()
+	}()
+}
+
+// recovered logs value and the stack of the panic, which is still in place while deferred
+// the location logged is 4 calls up, past Recover and panic, where the panic was raised
+func recovered(value interface{}) {
+	CRITICAL.Output(4, fmt.Sprintf("panic: %v\n%s", value, debug.Stack()))
+	configMu.RLock()
+	handle, again := FileHandle, repanic
+	configMu.RUnlock()
+	if file, ok := handle.(*os.File); ok {
+		file.Sync()
+	}
+	if again {
+		panic(value)
+	}
 }
 
 // DiscardLogging Disables logging
 func DiscardLogging() {
-	FileHandle = ioutil.Discard
-	refreshLogTypes()
+	useLogFile(ioutil.Discard, "")
 }
 
 // SetPrefix allows for changing the prefixes of ALL logs in lgr.
 func SetPrefix(prefix string){
+	configMu.Lock()
 	for _, n := range LogTypes {
         n.Prefix = prefix
     }
 	refreshLogTypes()
+	configMu.Unlock()
     INFO.Printf("NewPrefix(%+v)",prefix)
 }
 
 // SetPrefix allows for changing the prefix of a specific log.
 func (log *LogType) SetPrefix(prefix string){
+    configMu.Lock()
+    defer configMu.Unlock()
     log.Prefix = prefix
     refreshLogTypes()
 }
 
 // AppendPrefix allows for appending to the prefixes of ALL lgr logs 
 func AppendPrefix(prefix string){
+	configMu.Lock()
 	for _, n := range LogTypes {
         n.Prefix = prefix + n.Prefix
     }
 	refreshLogTypes()
+	configMu.Unlock()
     INFO.Printf("NewPrefix(%+v)",prefix)
 }
 
 // AppendPrefix allows for appending to the prefix of a specific log.
 func (log *LogType) AppendPrefix(prefix string){
+    configMu.Lock()
+    defer configMu.Unlock()
     log.Prefix = prefix + log.Prefix
     refreshLogTypes()
 }
@@ -331,6 +577,17 @@ func StringToLevel(levelName string) Level {
     return DefaultLogThreshold
 }
 
+// ParseLevel returns the level which has the name levelName, in any case, as StringToLevel does
+// but with an error for an unknown name
+func ParseLevel(levelName string) (Level, error) {
+    for _, n := range LogTypes {
+        if strings.EqualFold(n.Name, strings.TrimSpace(levelName)) {
+            return n.Level, nil
+        }
+    }
+    return DefaultLogThreshold, fmt.Errorf("unknown level %q, expected one of TRACE, DEBUG, INFO, MSG, WARN, ERROR, CRITICAL or FATAL", levelName)
+}
+
 // LevelToString takes type level and converts it to a string readable representation
 func LevelToString(level Level) string {
     for _, n := range LogTypes {
@@ -0,0 +1,289 @@
+package lgr
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// AdminHandler is an http.Handler to see and change the logging setup of a running program,
+// mount it on an admin port, ie. http.Handle("/debug/lgr", lgr.NewAdminHandler())
+//  GET         the thresholds, level overrides, loggers and outputs as JSON, see AdminState
+//  PUT, POST   a JSON AdminChange, every part of it is optional
+// a change is checked in full and then applied at once, so other goroutines never log with half of it
+type AdminHandler struct{}
+
+// NewAdminHandler returns the AdminHandler
+func NewAdminHandler() *AdminHandler {
+	return &AdminHandler{}
+}
+
+// AdminState is the logging setup as shown by the AdminHandler
+type AdminState struct {
+	LogThreshold	string				`json:"log_threshold"`
+	StdoutThreshold	string				`json:"stdout_threshold"`
+	LevelOverrides	string				`json:"level_overrides"`
+	Loggers			[]AdminLogger		`json:"loggers"`
+	Outputs			[]AdminOutput		`json:"outputs"`
+}
+
+// AdminLogger is a logger as shown by the AdminHandler
+type AdminLogger struct {
+	Name			string				`json:"name"`
+	Level			string				`json:"level"`
+	Enabled			bool				`json:"enabled"`
+	Prefix			[]string			`json:"prefix"`
+	Outputs			[]string			`json:"outputs"`
+	Filters			[]FilterConfig		`json:"filters"`
+}
+
+// AdminOutput is an output as shown by the AdminHandler
+type AdminOutput struct {
+	Name			string				`json:"name"`
+	Type			string				`json:"type"`
+	Threshold		string				`json:"threshold"`
+	Filters			[]FilterConfig		`json:"filters"`
+}
+
+// AdminChange is a change to the logging setup, sent to the AdminHandler
+// Outputs and Loggers are by name, an output name may be shared by several outputs which all change
+//  {"stdout_threshold": "DEBUG", "outputs": {"file": {"threshold": "TRACE"}}, "loggers": {"ERROR": {"filters": [{"keywords": ["db"]}]}}}
+type AdminChange struct {
+	LogThreshold	*string							`json:"log_threshold,omitempty"`
+	StdoutThreshold	*string							`json:"stdout_threshold,omitempty"`
+	LevelOverrides	*string							`json:"level_overrides,omitempty"`
+	Outputs			map[string]AdminOutputChange	`json:"outputs,omitempty"`
+	Loggers			map[string]AdminLoggerChange	`json:"loggers,omitempty"`
+}
+
+// AdminOutputChange changes the threshold and filters of an output
+// an empty threshold undoes an earlier one, see ResetOutputThreshold, so a console output follows stdout_threshold again
+type AdminOutputChange struct {
+	Threshold		*string				`json:"threshold,omitempty"`
+	Filters			*[]FilterConfig		`json:"filters,omitempty"`
+}
+
+// AdminLoggerChange changes the filters of a logger
+type AdminLoggerChange struct {
+	Filters			*[]FilterConfig		`json:"filters,omitempty"`
+}
+
+// ServeHTTP shows the logging setup, or changes it and shows the result
+func (handler *AdminHandler) ServeHTTP(response http.ResponseWriter, request *http.Request) {
+	switch request.Method {
+		case http.MethodGet, http.MethodHead:
+		case http.MethodPut, http.MethodPost:
+			var change AdminChange
+			decoder := json.NewDecoder(request.Body)
+			decoder.DisallowUnknownFields()
+			if err := decoder.Decode(&change); err != nil {
+				http.Error(response, "invalid change: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := applyAdminChange(&change); err != nil {
+				http.Error(response, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			response.Header().Set("Allow", "GET, HEAD, PUT, POST")
+			http.Error(response, "method not allowed", http.StatusMethodNotAllowed)
+			return
+	}
+	response.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(response)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(adminState())
+}
+
+// allOutputs returns every distinct output of the loggers of the Instance, in the order they are first found
+// the caller must hold settingsMu
+func (inst *Instance) allOutputs() []OutputI {
+	var outputs []OutputI
+	seen := make(map[*Output]bool)
+	for _, n := range inst.loggers {
+		for _, output := range n.source().Outputs {
+			if !seen[output.GetOutput()] {
+				seen[output.GetOutput()] = true
+				outputs = append(outputs, output)
+			}
+		}
+	}
+	return outputs
+}
+
+// adminState returns the current logging setup
+func adminState() AdminState {
+	overrides := LevelOverrides()
+	lowest := lowestOverride()
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+	state := AdminState{
+		LogThreshold:    LevelToString(std.logThreshold),
+		StdoutThreshold: LevelToString(std.outputThreshold),
+		LevelOverrides:  overrides,
+		Loggers:         []AdminLogger{},
+		Outputs:         []AdminOutput{},
+	}
+	for _, n := range std.loggers {
+		logger := AdminLogger{
+			Name:    n.Name,
+			Level:   LevelToString(n.Level),
+			Prefix:  []string{},
+			Outputs: []string{},
+			Filters: filterConfigs(n.AllowableFilters),
+		}
+		for _, prefix := range n.Prefix {
+			logger.Prefix = append(logger.Prefix, fmt.Sprint(prefix))
+		}
+		for _, output := range n.Outputs {
+			logger.Outputs = append(logger.Outputs, output.GetOutput().Name)
+			if n.Level >= output.GetOutput().threshold(n.logThreshold()) || lowest != nil && n.Level >= *lowest {
+				logger.Enabled = true
+			}
+		}
+		state.Loggers = append(state.Loggers, logger)
+	}
+	for _, output := range std.allOutputs() {
+		state.Outputs = append(state.Outputs, AdminOutput{
+			Name:      output.GetOutput().Name,
+			Type:      strings.TrimPrefix(fmt.Sprintf("%T", output), "*lgr."),
+			Threshold: LevelToString(output.GetOutput().threshold(std.logThreshold)),
+			Filters:   filterConfigs(output.GetOutput().Filters),
+		})
+	}
+	return state
+}
+
+// filterConfigs returns the filters as they are shown and changed by the AdminHandler
+func filterConfigs(filters Filters) []FilterConfig {
+	configs := []FilterConfig{}
+	for _, filter := range filters {
+		config := FilterConfig{Keywords: filter.Keywords, Level: LevelToString(levelCheck(Level(filter.Level)))}
+		for _, pattern := range filter.Patterns {
+			config.Patterns = append(config.Patterns, pattern.String())
+		}
+		configs = append(configs, config)
+	}
+	return configs
+}
+
+// applyAdminChange checks the whole of change, then applies it while holding settingsMu
+func applyAdminChange(change *AdminChange) error {
+	var logLevel, stdoutLevel *Level
+	var overrides []levelOverride
+	if change.LogThreshold != nil {
+		level, err := ParseLevel(*change.LogThreshold)
+		if err != nil {
+			return fmt.Errorf("log_threshold: %s", err)
+		}
+		logLevel = &level
+	}
+	if change.StdoutThreshold != nil {
+		level, err := ParseLevel(*change.StdoutThreshold)
+		if err != nil {
+			return fmt.Errorf("stdout_threshold: %s", err)
+		}
+		stdoutLevel = &level
+	}
+	if change.LevelOverrides != nil {
+		var err error
+		if overrides, err = parseLevelOverrides(*change.LevelOverrides); err != nil {
+			return fmt.Errorf("level_overrides: %s", err)
+		}
+	}
+
+	type outputChange struct {
+		output		*Output
+		threshold	*Level
+		reset		bool
+		filters		Filters
+		setFilters	bool
+	}
+	var outputChanges []outputChange
+	settingsMu.RLock()
+	outputs := std.allOutputs()
+	settingsMu.RUnlock()
+	for name, outputConfig := range change.Outputs {
+		found := false
+		for _, output := range outputs {
+			if output.GetOutput().Name != name {
+				continue
+			}
+			found = true
+			changed := outputChange{output: output.GetOutput()}
+			if outputConfig.Threshold != nil && *outputConfig.Threshold == "" {
+				changed.reset = true
+			} else if outputConfig.Threshold != nil {
+				level, err := ParseLevel(*outputConfig.Threshold)
+				if err != nil {
+					return fmt.Errorf("output %s: %s", name, err)
+				}
+				changed.threshold = &level
+			}
+			if outputConfig.Filters != nil {
+				filters, err := buildFilters(*outputConfig.Filters)
+				if err != nil {
+					return fmt.Errorf("output %s: %s", name, err)
+				}
+				changed.filters, changed.setFilters = filters, true
+			}
+			outputChanges = append(outputChanges, changed)
+		}
+		if !found {
+			return fmt.Errorf("output %s: no output has this name", name)
+		}
+	}
+
+	loggerFilters := make(map[*LoggerT]Filters)
+	for name, loggerConfig := range change.Loggers {
+		logger := std.loggerNamed(name)
+		if logger == nil {
+			return fmt.Errorf("logger %s: no logger has this name", name)
+		}
+		if loggerConfig.Filters != nil {
+			filters, err := buildFilters(*loggerConfig.Filters)
+			if err != nil {
+				return fmt.Errorf("logger %s: %s", name, err)
+			}
+			loggerFilters[logger] = filters
+		}
+	}
+
+	// everything is valid, apply it at once
+	settingsMu.Lock()
+	if change.LevelOverrides != nil {
+		setLevelOverrides(overrides)
+	}
+	if logLevel != nil {
+		std.logThreshold = *logLevel
+	}
+	if stdoutLevel != nil {
+		std.outputThreshold = *stdoutLevel
+	}
+	for _, changed := range outputChanges {
+		if changed.reset {
+			changed.output.outputThreshold = changed.output.followed
+		} else if changed.threshold != nil {
+			changed.output.outputThreshold = changed.threshold
+		}
+		if changed.setFilters {
+			changed.output.Filters = changed.filters
+		}
+	}
+	for logger, filters := range loggerFilters {
+		logger.AllowableFilters = filters
+	}
+	settingsMu.Unlock()
+	INFO.Printf("AdminChange(%s)", describeAdminChange(change))
+	return nil
+}
+
+// describeAdminChange renders change as JSON for the log
+func describeAdminChange(change *AdminChange) string {
+	described, err := json.Marshal(change)
+	if err != nil {
+		return err.Error()
+	}
+	return string(described)
+}
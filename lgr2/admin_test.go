@@ -0,0 +1,30 @@
+package lgr
+
+import "testing"
+
+// TestAdminOutputThresholdReset checks the console follows stdout_threshold again once its own threshold is cleared
+func TestAdminOutputThresholdReset(t *testing.T) {
+	saved := StdoutThreshold()
+	defer SetStdoutThreshold(saved)
+	console := stdout.GetOutput()
+	defer console.ResetOutputThreshold()
+
+	trace, empty := "TRACE", ""
+	if err := applyAdminChange(&AdminChange{Outputs: map[string]AdminOutputChange{"console": {Threshold: &trace}}}); err != nil {
+		t.Fatal(err)
+	}
+	SetStdoutThreshold(LevelWarn)
+	if threshold := console.Threshold(); threshold != LevelTrace {
+		t.Errorf("console threshold %s, expected TRACE set by the change", LevelToString(threshold))
+	}
+	if err := applyAdminChange(&AdminChange{Outputs: map[string]AdminOutputChange{"console": {Threshold: &empty}}}); err != nil {
+		t.Fatal(err)
+	}
+	if threshold := console.Threshold(); threshold != LevelWarn {
+		t.Errorf("console threshold %s, expected WARN of the stdout threshold", LevelToString(threshold))
+	}
+	SetStdoutThreshold(LevelError)
+	if threshold := console.Threshold(); threshold != LevelError {
+		t.Errorf("console threshold %s, expected ERROR of the stdout threshold", LevelToString(threshold))
+	}
+}
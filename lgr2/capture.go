@@ -0,0 +1,99 @@
+package lgr
+
+import (
+	"log"
+	"strings"
+)
+
+// sniffNames are the words, at the start of a message, which select its level when sniffing
+var sniffNames = map[string]Level{
+	"TRACE":    LevelTrace,
+	"DEBUG":    LevelDebug,
+	"INFO":     LevelInfo,
+	"MSG":      LevelMsg,
+	"NOTICE":   LevelMsg,
+	"WARN":     LevelWarn,
+	"WARNING":  LevelWarn,
+	"ERROR":    LevelError,
+	"ERR":      LevelError,
+	"CRITICAL": LevelCritical,
+	"CRIT":     LevelCritical,
+	"FATAL":    LevelFatal,
+}
+
+// CaptureStandardLog redirects the global log package, log.Printf etc., into lgr at level
+// see NewLogWriter for sniff
+func CaptureStandardLog(level Level, sniff bool) {
+	CaptureLogger(log.Default(), level, sniff)
+}
+
+// CaptureLogger redirects a *log.Logger, ie. of a third-party package, into lgr at level
+// its flags are cleared as lgr adds the time and caller itself, its prefix is kept,
+// sniffing looks for the level name after it
+// see NewLogWriter for sniff
+func CaptureLogger(logger *log.Logger, level Level, sniff bool) {
+	logger.SetFlags(0)
+	writer := NewLogWriter(level, sniff)
+	writer.Prefix = logger.Prefix()
+	logger.SetOutput(writer)
+}
+
+// LogWriter is an io.Writer which logs everything written to it through the lgr logger of its level
+// as the log package makes a single Write for each message, each Write is logged as one Record
+type LogWriter struct {
+	Level	Level
+	Sniff	bool				// Sniff picks the level from a leading level name, ie. "ERROR:" or "[warn]"
+	Prefix	string				// Prefix is that of the captured *log.Logger, the level name is looked for after it
+}
+
+// NewLogWriter returns an io.Writer which logs each message written to it at level,
+// when sniff is true a message starting with a level name, such as ERROR, warn: or [warning], is logged at that level instead
+func NewLogWriter(level Level, sniff bool) *LogWriter {
+	return &LogWriter{Level: level, Sniff: sniff}
+}
+
+// Write logs p as a Record
+func (writer *LogWriter) Write(p []byte) (n int, err error) {
+	message := strings.TrimSuffix(string(p), "\n")
+	level := writer.Level
+	if writer.Sniff && strings.HasPrefix(message, writer.Prefix) {
+		var rest string
+		level, rest = sniffLevel(level, message[len(writer.Prefix):])
+		message = writer.Prefix + rest
+	}
+	logger := std.levelLogger(level)
+	return len(p), logger.dispatch(logger.newRecord(message))
+}
+
+// sniffLevel looks for a level name at the start of line, in brackets or followed by a colon, in any case,
+// returning its level and the line without it
+// a level name in upper case on its own, ie. "ERROR connecting", sets the level too, but is kept as part of the line
+// otherwise level and line are returned as they were, so "Error connecting to db" is left alone
+func sniffLevel(level Level, line string) (Level, string) {
+	trimmed := strings.TrimLeft(line, " \t")
+	end := strings.IndexAny(trimmed, " \t:]")
+	if end < 0 {
+		end = len(trimmed)
+	}
+	word := trimmed[:end]
+	bracketed := strings.HasPrefix(word, "[")
+	sniffed, ok := sniffNames[strings.ToUpper(strings.TrimPrefix(word, "["))]
+	if !ok {
+		return level, line
+	}
+	rest := trimmed[end:]
+	switch {
+		case bracketed:
+			if !strings.HasPrefix(rest, "]") {
+				return level, line
+			}
+			rest = strings.TrimPrefix(rest[1:], ":")
+		case strings.HasPrefix(rest, ":"):
+			rest = rest[1:]
+		case word == strings.ToUpper(word):
+			return sniffed, line
+		default:
+			return level, line
+	}
+	return sniffed, strings.TrimLeft(rest, " \t")
+}
@@ -0,0 +1,29 @@
+package lgr
+
+import "testing"
+
+func TestSniffLevel(t *testing.T) {
+	tests := []struct {
+		line    string
+		level   Level
+		message string
+	}{
+		{"ERROR: disk full", LevelError, "disk full"},
+		{"error: disk full", LevelError, "disk full"},
+		{"[warn] disk full", LevelWarn, "disk full"},
+		{"[WARNING]: disk full", LevelWarn, "disk full"},
+		{"  CRIT disk full", LevelCritical, "  CRIT disk full"},
+		{"ERROR", LevelError, "ERROR"},
+		{"Error connecting to db", LevelInfo, "Error connecting to db"},
+		{"info about the thing", LevelInfo, "info about the thing"},
+		{"[warn disk full", LevelInfo, "[warn disk full"},
+		{"errors: 3", LevelInfo, "errors: 3"},
+		{"", LevelInfo, ""},
+	}
+	for _, test := range tests {
+		level, message := sniffLevel(LevelInfo, test.line)
+		if level != test.level || message != test.message {
+			t.Errorf("sniffLevel(%q) = %s, %q, expected %s, %q", test.line, LevelToString(level), message, LevelToString(test.level), test.message)
+		}
+	}
+}
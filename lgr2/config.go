@@ -0,0 +1,556 @@
+package lgr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/fatih/color"
+	"gopkg.in/yaml.v3"
+)
+
+// Config describes the whole logging setup, as read by LoadConfig
+//
+//  log_threshold: INFO
+//  stdout_threshold: MSG
+//  flags: [date, time, shortfile]
+//  outputs:
+//    console: {type: console}
+//    file: {type: file, path: /var/log/app.log, format: logfmt, rotate_every: daily, max_age: 30d}
+//  loggers:
+//    DEBUG: {outputs: [file]}
+//
+// loggers without an entry, or without outputs of their own, write to every output
+type Config struct {
+	LogThreshold	string					`json:"log_threshold" yaml:"log_threshold" toml:"log_threshold"`
+	StdoutThreshold	string					`json:"stdout_threshold" yaml:"stdout_threshold" toml:"stdout_threshold"`
+	Flags			[]string				`json:"flags" yaml:"flags" toml:"flags"`
+	Prefix			[]string				`json:"prefix" yaml:"prefix" toml:"prefix"`
+	LevelOverrides	*string					`json:"level_overrides" yaml:"level_overrides" toml:"level_overrides"`	// LevelOverrides, see SetLevelOverrides, are kept when absent, "" removes them
+	StackLevel		string					`json:"stack_level" yaml:"stack_level" toml:"stack_level"`		// StackLevel, see SetStackLevel
+	StackDepth		int						`json:"stack_depth" yaml:"stack_depth" toml:"stack_depth"`
+	StackFilter		[]string				`json:"stack_filter" yaml:"stack_filter" toml:"stack_filter"`
+	Columns			*ColumnLayout			`json:"columns" yaml:"columns" toml:"columns"`					// Columns are the widths of the columns format
+	Outputs			map[string]OutputConfig	`json:"outputs" yaml:"outputs" toml:"outputs"`
+	Loggers			map[string]LoggerConfig	`json:"loggers" yaml:"loggers" toml:"loggers"`
+}
+
+// LoggerConfig is the setup of one logger, by its name, ie. DEBUG
+type LoggerConfig struct {
+	Outputs			[]string				`json:"outputs" yaml:"outputs" toml:"outputs"`
+	Prefix			[]string				`json:"prefix" yaml:"prefix" toml:"prefix"`
+	Flags			[]string				`json:"flags" yaml:"flags" toml:"flags"`
+	Filters			[]FilterConfig			`json:"filters" yaml:"filters" toml:"filters"`
+	Highlights		[]FilterConfig			`json:"highlights" yaml:"highlights" toml:"highlights"`
+}
+
+// FilterConfig is a Filter, Patterns are regular expressions
+type FilterConfig struct {
+	Keywords		[]string				`json:"keywords" yaml:"keywords" toml:"keywords"`
+	Patterns		[]string				`json:"patterns" yaml:"patterns" toml:"patterns"`
+	Level			string					`json:"level" yaml:"level" toml:"level"`
+	Style			[]string				`json:"style" yaml:"style" toml:"style"`
+}
+
+// OutputConfig is the setup of one output, Type is one of
+// console, file, json (to stdout), syslog, journal or discard
+// only the settings of its Type are used
+type OutputConfig struct {
+	Type			string					`json:"type" yaml:"type" toml:"type"`
+	Format			string					`json:"format" yaml:"format" toml:"format"`
+	Threshold		string					`json:"threshold" yaml:"threshold" toml:"threshold"`
+	Filters			[]FilterConfig			`json:"filters" yaml:"filters" toml:"filters"`
+
+	// file
+	Path			string					`json:"path" yaml:"path" toml:"path"`
+	MaxSize			int64					`json:"max_size" yaml:"max_size" toml:"max_size"`
+	MaxBackups		int						`json:"max_backups" yaml:"max_backups" toml:"max_backups"`
+	Compress		bool					`json:"compress" yaml:"compress" toml:"compress"`
+	TimestampBackups	bool				`json:"timestamp_backups" yaml:"timestamp_backups" toml:"timestamp_backups"`
+	RotateEvery		string					`json:"rotate_every" yaml:"rotate_every" toml:"rotate_every"`
+	UTC				bool					`json:"utc" yaml:"utc" toml:"utc"`
+	MaxAge			string					`json:"max_age" yaml:"max_age" toml:"max_age"`
+	MaxTotalSize	int64					`json:"max_total_size" yaml:"max_total_size" toml:"max_total_size"`
+	Symlink			string					`json:"symlink" yaml:"symlink" toml:"symlink"`
+
+	// syslog and journal
+	Network			string					`json:"network" yaml:"network" toml:"network"`
+	Address			string					`json:"address" yaml:"address" toml:"address"`
+	Facility		int						`json:"facility" yaml:"facility" toml:"facility"`
+	AppName			string					`json:"app_name" yaml:"app_name" toml:"app_name"`
+	RFC3164			bool					`json:"rfc3164" yaml:"rfc3164" toml:"rfc3164"`
+	SocketPath		string					`json:"socket_path" yaml:"socket_path" toml:"socket_path"`
+
+	// Async places the output behind an AsyncOutput with a queue of this size
+	Async			int						`json:"async" yaml:"async" toml:"async"`
+	Overflow		string					`json:"overflow" yaml:"overflow" toml:"overflow"`
+}
+
+// configOutputs builds each Type of output from its OutputConfig
+var configOutputs = map[string]func(config OutputConfig) (OutputI, error){
+	"console": func(config OutputConfig) (OutputI, error) {
+		return NewConsoleColorOutput(), nil
+	},
+	"json": func(config OutputConfig) (OutputI, error) {
+		return NewJSONOutput(os.Stdout), nil
+	},
+	"discard": func(config OutputConfig) (OutputI, error) {
+		return NewDiscardOutput(), nil
+	},
+	"file": func(config OutputConfig) (OutputI, error) {
+		output := &FileOutput{
+			MaxSize:          config.MaxSize,
+			MaxBackups:       config.MaxBackups,
+			Compress:         config.Compress,
+			TimestampBackups: config.TimestampBackups,
+			UTC:              config.UTC,
+			MaxTotalSize:     config.MaxTotalSize,
+			Symlink:          config.Symlink,
+		}
+		output.Name = "file"
+		var err error
+		if output.RotateEvery, err = ParsePeriod(config.RotateEvery); err != nil {
+			return nil, err
+		}
+		if output.MaxAge, err = parseAge(config.MaxAge); err != nil {
+			return nil, err
+		}
+		if config.Path == "" {
+			return nil, fmt.Errorf("file output needs a path")
+		}
+		if _, err := output.SetLogFile(config.Path); err != nil {
+			return nil, err
+		}
+		return output, nil
+	},
+	"syslog": func(config OutputConfig) (OutputI, error) {
+		output, err := NewSyslogOutput(config.Network, config.Address, config.Facility, config.AppName)
+		if err != nil {
+			return nil, err
+		}
+		output.RFC3164 = config.RFC3164
+		return output, nil
+	},
+}
+
+// LoadConfig reads the logging setup from a JSON, YAML or TOML file, chosen by its extension,
+// and applies it, see Config
+func LoadConfig(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("Failed to read log config:%s\n%s", path, err)
+	}
+	var config Config
+	switch strings.ToLower(filepath.Ext(path)) {
+		case ".json":
+			err = json.Unmarshal(data, &config)
+		case ".yaml", ".yml":
+			err = yaml.Unmarshal(data, &config)
+		case ".toml":
+			err = toml.Unmarshal(data, &config)
+		default:
+			return fmt.Errorf("Failed to read log config:%s\nunknown extension, expected .json, .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to read log config:%s\n%s", path, err)
+	}
+	if err := ApplyConfig(&config); err != nil {
+		return fmt.Errorf("Failed to apply log config:%s\n%s", path, err)
+	}
+	return nil
+}
+
+// configuredOutputs are the outputs built by ApplyConfig, or from the environment, guarded by settingsMu
+// they are closed once a later ApplyConfig leaves no logger writing to them
+var configuredOutputs []OutputI
+
+// ApplyConfig sets up the loggers as described by config
+// everything is built and checked first, so nothing is changed when an error is returned,
+// the outputs built are closed again
+// outputs of an earlier config, which no logger writes to any more, are closed
+func ApplyConfig(config *Config) (err error) {
+	logLevel, stdoutLevel := LogThreshold(), StdoutThreshold()
+	if config.LogThreshold != "" {
+		if logLevel, err = ParseLevel(config.LogThreshold); err != nil {
+			return err
+		}
+	}
+	if config.StdoutThreshold != "" {
+		if stdoutLevel, err = ParseLevel(config.StdoutThreshold); err != nil {
+			return err
+		}
+	}
+	flags := -1
+	if config.Flags != nil {
+		if flags, err = parseFlags(config.Flags); err != nil {
+			return err
+		}
+	}
+	var overrides []levelOverride
+	if config.LevelOverrides != nil {
+		if overrides, err = parseLevelOverrides(*config.LevelOverrides); err != nil {
+			return fmt.Errorf("level_overrides: %s", err)
+		}
+	}
+	var stackLevelSet Level
+	if config.StackLevel != "" {
+		if stackLevelSet, err = ParseLevel(config.StackLevel); err != nil {
+			return fmt.Errorf("stack_level: %s", err)
+		}
+	}
+
+	outputs := make(map[string]OutputI, len(config.Outputs))
+	var allOutputs []OutputI
+	defer func() {
+		if err != nil {
+			for _, output := range allOutputs {
+				closeOutput(output)
+			}
+		}
+	}()
+	for name, outputConfig := range config.Outputs {
+		output, err := buildOutput(name, outputConfig)
+		if err != nil {
+			return fmt.Errorf("output %s: %s", name, err)
+		}
+		outputs[name] = output
+		allOutputs = append(allOutputs, output)
+	}
+
+	type loggerSetup struct {
+		logger		*LoggerT
+		outputs		[]OutputI
+		flags		int
+		prefix		PrefixList
+		filters		Filters
+		highlights	Filters
+	}
+	var setups []loggerSetup
+	for name, loggerConfig := range config.Loggers {
+		logger := std.loggerNamed(name)
+		if logger == nil {
+			return fmt.Errorf("logger %s: no logger has this name", name)
+		}
+		setup := loggerSetup{logger: logger, flags: -1}
+		for _, outputName := range loggerConfig.Outputs {
+			output, ok := outputs[outputName]
+			if !ok {
+				return fmt.Errorf("logger %s: output %s is not in outputs", name, outputName)
+			}
+			setup.outputs = append(setup.outputs, output)
+		}
+		if loggerConfig.Flags != nil {
+			if setup.flags, err = parseFlags(loggerConfig.Flags); err != nil {
+				return fmt.Errorf("logger %s: %s", name, err)
+			}
+		}
+		if loggerConfig.Prefix != nil {
+			setup.prefix = PrefixList{}
+			for _, prefix := range loggerConfig.Prefix {
+				setup.prefix = append(setup.prefix, prefix)
+			}
+		}
+		if setup.filters, err = buildFilters(loggerConfig.Filters); err != nil {
+			return fmt.Errorf("logger %s: %s", name, err)
+		}
+		if setup.highlights, err = buildFilters(loggerConfig.Highlights); err != nil {
+			return fmt.Errorf("logger %s: %s", name, err)
+		}
+		setups = append(setups, setup)
+	}
+
+	// everything is valid, apply it
+	settingsMu.Lock()
+	for _, n := range std.loggers {
+		if allOutputs != nil {
+			n.Outputs = allOutputs
+		}
+		if flags >= 0 {
+			n.Flags = flags
+		}
+		if config.Prefix != nil {
+			n.Prefix = nil
+			for _, prefix := range config.Prefix {
+				n.Prefix = append(n.Prefix, prefix)
+			}
+		}
+	}
+	for _, setup := range setups {
+		if setup.outputs != nil {
+			setup.logger.Outputs = setup.outputs
+		}
+		if setup.flags >= 0 {
+			setup.logger.Flags = setup.flags
+		}
+		if setup.prefix != nil {
+			setup.logger.Prefix = setup.prefix
+		}
+		if setup.filters != nil {
+			setup.logger.AllowableFilters = setup.filters
+		}
+		if setup.highlights != nil {
+			setup.logger.HighlightFilters = setup.highlights
+		}
+	}
+	if config.StackLevel != "" {
+		stackLevel = stackLevelSet
+	}
+	if config.StackDepth > 0 {
+		stackDepth = config.StackDepth
+	}
+	if config.StackFilter != nil {
+		stackFilters = config.StackFilter
+	}
+	if config.Columns != nil {
+		columnLayout = *config.Columns
+	}
+	if config.LevelOverrides != nil {
+		setLevelOverrides(overrides)
+	}
+	replaced := retireOutputs(allOutputs)
+	settingsMu.Unlock()
+	for _, output := range replaced {
+		closeOutput(output)
+	}
+	SetLogThreshold(logLevel)
+	SetStdoutThreshold(stdoutLevel)
+	return nil
+}
+
+// retireOutputs adds built to configuredOutputs, and takes out and returns those which no logger of the default Instance writes to any more
+// the caller must hold settingsMu
+func retireOutputs(built []OutputI) (replaced []OutputI) {
+	inUse := make(map[OutputI]bool)
+	for _, output := range std.allOutputs() {
+		inUse[output] = true
+	}
+	var kept []OutputI
+	for _, output := range append(configuredOutputs, built...) {
+		if inUse[output] {
+			kept = append(kept, output)
+		} else {
+			replaced = append(replaced, output)
+		}
+	}
+	configuredOutputs = kept
+	return replaced
+}
+
+// closeOutput closes output when it holds a file or connection, an AsyncOutput writes out its queue and closes the output it wraps
+func closeOutput(output OutputI) {
+	switch closer := output.(type) {
+		case *AsyncOutput:
+			closer.Close()
+			closeOutput(closer.output)
+		case interface{ Close() error }:
+			closer.Close()
+	}
+}
+
+// buildOutput creates the output described by config
+// everything is checked before it is created, so that nothing is left open when an error is returned
+func buildOutput(name string, config OutputConfig) (OutputI, error) {
+	build, ok := configOutputs[strings.ToLower(config.Type)]
+	if !ok {
+		return nil, fmt.Errorf("unknown type %q", config.Type)
+	}
+	format, err := ParseFormat(config.Format)
+	if err != nil {
+		return nil, err
+	}
+	filters, err := buildFilters(config.Filters)
+	if err != nil {
+		return nil, err
+	}
+	var threshold Level
+	if config.Threshold != "" {
+		if threshold, err = ParseLevel(config.Threshold); err != nil {
+			return nil, err
+		}
+	}
+	var policy OverflowPolicy
+	if config.Async > 0 {
+		if policy, err = parseOverflow(config.Overflow); err != nil {
+			return nil, err
+		}
+	}
+	output, err := build(config)
+	if err != nil {
+		return nil, err
+	}
+	switch typed := output.(type) {
+		case *ConsoleColorOutput:
+			typed.Format = format
+		case *FileOutput:
+			typed.Format = format
+	}
+	settings := output.GetOutput()
+	settings.Name = name
+	settings.Filters = filters
+	if config.Threshold != "" {
+		settings.SetOutputThreshold(threshold)
+	}
+	if config.Async > 0 {
+		output = NewAsyncOutput(output, config.Async, policy)
+	}
+	return output, nil
+}
+
+// buildFilters creates Filters from their config
+func buildFilters(configs []FilterConfig) (Filters, error) {
+	var filters Filters
+	for _, config := range configs {
+		filter := Filter{Keywords: config.Keywords}
+		if config.Level != "" {
+			level, err := ParseLevel(config.Level)
+			if err != nil {
+				return nil, err
+			}
+			filter.Level = int(level)
+		}
+		for _, pattern := range config.Patterns {
+			compiled, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, err
+			}
+			filter.Patterns = append(filter.Patterns, compiled)
+		}
+		if config.Style != nil {
+			style, err := parseStyle(config.Style)
+			if err != nil {
+				return nil, err
+			}
+			filter.Style = style
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+// ParseFormat returns the Format named text, logfmt, json or columns, empty is text
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+		case "", "text":
+			return FormatText, nil
+		case "logfmt":
+			return FormatLogfmt, nil
+		case "json":
+			return FormatJSON, nil
+		case "columns":
+			return FormatColumns, nil
+	}
+	return FormatText, fmt.Errorf("unknown format %q, expected text, logfmt, json or columns", name)
+}
+
+// ParsePeriod returns the Period named never, hourly or daily, empty is never
+func ParsePeriod(name string) (Period, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+		case "", "never":
+			return RotateNever, nil
+		case "hourly":
+			return RotateHourly, nil
+		case "daily":
+			return RotateDaily, nil
+	}
+	return RotateNever, fmt.Errorf("unknown rotation %q, expected never, hourly or daily", name)
+}
+
+// parseOverflow returns the OverflowPolicy named block, drop_newest or drop_oldest, empty is block
+func parseOverflow(name string) (OverflowPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+		case "", "block":
+			return OverflowBlock, nil
+		case "drop_newest":
+			return OverflowDropNewest, nil
+		case "drop_oldest":
+			return OverflowDropOldest, nil
+	}
+	return OverflowBlock, fmt.Errorf("unknown overflow %q, expected block, drop_newest or drop_oldest", name)
+}
+
+// parseAge is time.ParseDuration which also accepts whole days, ie. 30d
+func parseAge(age string) (time.Duration, error) {
+	if age == "" {
+		return 0, nil
+	}
+	if strings.HasSuffix(age, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(age, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q", age)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(age)
+}
+
+// flagNames are the names of the log flag constants used in a Config
+var flagNames = map[string]int{
+	"date":         log.Ldate,
+	"time":         log.Ltime,
+	"microseconds": log.Lmicroseconds,
+	"longfile":     log.Llongfile,
+	"shortfile":    log.Lshortfile,
+	"utc":          log.LUTC,
+	"stdflags":     log.LstdFlags,
+}
+
+// parseFlags combines the named log flags, see flagNames
+func parseFlags(names []string) (int, error) {
+	var flags int
+	for _, name := range names {
+		flag, ok := flagNames[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return 0, fmt.Errorf("unknown flag %q, expected date, time, microseconds, longfile, shortfile, utc or stdflags", name)
+		}
+		flags |= flag
+	}
+	return flags, nil
+}
+
+// styleNames are the names of the color attributes used for the style of a highlight in a Config
+// colors are foreground, bg_<color> is the background
+var styleNames = map[string]color.Attribute{
+	"bold":       color.Bold,
+	"faint":      color.Faint,
+	"italic":     color.Italic,
+	"underline":  color.Underline,
+	"blink":      color.BlinkSlow,
+	"reverse":    color.ReverseVideo,
+	"black":      color.FgBlack,
+	"red":        color.FgRed,
+	"green":      color.FgGreen,
+	"yellow":     color.FgYellow,
+	"blue":       color.FgBlue,
+	"magenta":    color.FgMagenta,
+	"cyan":       color.FgCyan,
+	"white":      color.FgWhite,
+	"bg_black":   color.BgBlack,
+	"bg_red":     color.BgRed,
+	"bg_green":   color.BgGreen,
+	"bg_yellow":  color.BgYellow,
+	"bg_blue":    color.BgBlue,
+	"bg_magenta": color.BgMagenta,
+	"bg_cyan":    color.BgCyan,
+	"bg_white":   color.BgWhite,
+}
+
+// parseStyle combines the named attributes, see styleNames, into a color
+func parseStyle(names []string) (*color.Color, error) {
+	style := color.New()
+	for _, name := range names {
+		attribute, ok := styleNames[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown style %q", name)
+		}
+		style.Add(attribute)
+	}
+	return style, nil
+}
@@ -0,0 +1,28 @@
+package lgr
+
+import "testing"
+
+// TestApplyConfigLevelOverrides checks a config without level_overrides keeps those set at runtime, and "" removes them
+func TestApplyConfigLevelOverrides(t *testing.T) {
+	defer setLevelOverrides(nil)
+	if err := SetLevelOverrides("db/*=TRACE"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ApplyConfig(&Config{}); err != nil {
+		t.Fatal(err)
+	}
+	if rules := LevelOverrides(); rules != "db/*=TRACE" {
+		t.Errorf("overrides %q after a config without level_overrides", rules)
+	}
+	invalid := "db/*=LOUD"
+	if err := ApplyConfig(&Config{LevelOverrides: &invalid}); err == nil {
+		t.Error("expected an invalid level override to be refused")
+	}
+	none := ""
+	if err := ApplyConfig(&Config{LevelOverrides: &none}); err != nil {
+		t.Fatal(err)
+	}
+	if rules := LevelOverrides(); rules != "" {
+		t.Errorf("overrides %q after a config with empty level_overrides", rules)
+	}
+}
@@ -0,0 +1,96 @@
+package lgr
+
+import "context"
+
+// contextKey is the type of the keys lgr stores its values in a context.Context under
+type contextKey int
+
+const (
+	instanceKey contextKey = iota
+	fieldsKey
+)
+
+// contextValue is a value taken from every context by ContextFields, see AddContextKey
+type contextValue struct {
+	name	string
+	key		interface{}
+}
+
+// contextValues are those added by AddContextKey, guarded by settingsMu
+var contextValues []contextValue
+
+// NewContext returns a copy of ctx which carries inst, see FromContext
+func NewContext(ctx context.Context, inst *Instance) context.Context {
+	return context.WithValue(ctx, instanceKey, inst)
+}
+
+// ContextWith returns a copy of ctx which carries the key/value pairs, after those it already carries
+// ie. in an http.Handler: ctx := lgr.ContextWith(r.Context(), "request", id, "user", user)
+func ContextWith(ctx context.Context, keyvals ...interface{}) context.Context {
+	fields, _ := ctx.Value(fieldsKey).(Fields)
+	return context.WithValue(ctx, fieldsKey, fields.With(keyvals...))
+}
+
+// AddContextKey adds the value a context holds for key, whenever there is one, to ContextFields as name
+// so that values which other packages store in the context, ie. a request id, are logged without using ContextWith
+func AddContextKey(name string, key interface{}) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	contextValues = append(contextValues, contextValue{name: name, key: key})
+}
+
+// ContextFields returns the fields carried by ctx, from ContextWith, followed by those of AddContextKey
+func ContextFields(ctx context.Context) Fields {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey).(Fields)
+	settingsMu.RLock()
+	values := contextValues
+	settingsMu.RUnlock()
+	for _, value := range values {
+		if found := ctx.Value(value.key); found != nil {
+			fields = fields.With(value.name, found)
+		}
+	}
+	return fields
+}
+
+// FromContext returns the Instance carried by ctx, or the default Instance,
+// whose loggers add the ContextFields of ctx to every line
+// ie. lgr.FromContext(ctx).ERROR.Println("I've stubbed my toe")
+// a nil ctx returns the default Instance
+func FromContext(ctx context.Context) *Instance {
+	if ctx == nil {
+		return std
+	}
+	inst, _ := ctx.Value(instanceKey).(*Instance)
+	if inst == nil {
+		inst = std
+	}
+	fields := ContextFields(ctx)
+	if len(fields) == 0 {
+		return inst
+	}
+	return inst.withFields(fields)
+}
+
+// Context returns a copy of the logger which adds the ContextFields of ctx to every line
+func (log *LoggerT) Context(ctx context.Context) *LoggerT {
+	return log.withFields(ContextFields(ctx))
+}
+
+// PrintContext logs as Print does, with the ContextFields of ctx
+func (log *LoggerT) PrintContext(ctx context.Context, v ...interface{}) {
+	log.Context(ctx).Print(v...)
+}
+
+// PrintfContext logs as Printf does, with the ContextFields of ctx
+func (log *LoggerT) PrintfContext(ctx context.Context, format string, v ...interface{}) {
+	log.Context(ctx).Printf(format, v...)
+}
+
+// PrintlnContext logs as Println does, with the ContextFields of ctx
+func (log *LoggerT) PrintlnContext(ctx context.Context, v ...interface{}) {
+	log.Context(ctx).Println(v...)
+}
@@ -0,0 +1,22 @@
+package lgr
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFromContext(t *testing.T) {
+	if inst := FromContext(nil); inst != std {
+		t.Error("FromContext(nil) is not the default Instance")
+	}
+	inst := New(NewDiscardOutput())
+	defer inst.Close()
+	ctx := NewContext(context.Background(), inst)
+	if found := FromContext(ctx); found != inst {
+		t.Error("FromContext did not return the Instance of the context")
+	}
+	found := FromContext(ContextWith(ctx, "request", 7))
+	if found.base() != inst || len(found.ERROR.Fields) != 1 || found.ERROR.Fields[0].Key != "request" {
+		t.Errorf("FromContext returned an Instance without the fields of the context")
+	}
+}
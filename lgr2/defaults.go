@@ -1,8 +1,6 @@
 package lgr
 
 import "log"
-import "io"
-import "io/ioutil"
 import "github.com/fatih/color"
 
 
@@ -13,39 +11,34 @@ import "github.com/fatih/color"
 // TRACE being for detailed reporting whereas FATAL is for _total_ failure
 const (
     // LevelTrace Excessive User Output
-	levelTrace Level = iota
+	LevelTrace Level = iota
     // LevelDebug Detailed User Output
-	levelDebug
+	LevelDebug
     // LevelInfo Elevated User Output
-	levelInfo			
+	LevelInfo			
     // LevelMsg Standard User Output
-	levelMsg
+	LevelMsg
     // LevelWarn Non-Critical Errors
-	levelWarn
+	LevelWarn
     // LevelError Important Errors
-	levelError
+	LevelError
     // LevelCritical Disrupting Errors
-	levelCritical
+	LevelCritical
     // LevelFatal System destroying, flee the building errors
-	levelFatal
-	defaultLogThreshold    = levelInfo
-	defaultStdoutThreshold = levelMsg
+	LevelFatal
+	defaultLogThreshold    = LevelInfo
+	defaultStdoutThreshold = LevelMsg
 	defaultFlags           = log.Ldate|log.Ltime|log.Lshortfile
 )
 
 // local defaults
 var (
-	// don't export these, they should be set 
-	logThreshold    Level    = defaultLogThreshold
-	outputThreshold Level    = defaultStdoutThreshold
-
-	// FileHandle is the handle for the log file to write to
-	fileHandle        = ioutil.Discard
+	// stdout is the console Output, it follows the stdout threshold of the default Instance
+	stdout = NewConsoleColorOutput()
 
 	// default Outputs to write to
-	// Outputs should shadow (aka implement io.Writer)
-	defaultOutputs = []io.Writer{
-		fileHandle,
+	defaultOutputs = []OutputI{
+		stdout,
 	}
 	
 	defaultPrefixName = true
@@ -53,90 +46,110 @@ var (
 
 )
 
+// newLevelLoggers returns the loggers TRACE to FATAL, in order, writing to outputs
+// they still have to be readied with NewLogger
+func newLevelLoggers(outputs []OutputI) []*LoggerT {
+	return []*LoggerT{
+		&LoggerT{
+	        Level: LevelTrace, 
+	        Name:   "TRACE",
+			PrefixName: defaultPrefixName,
+	        Prefix: defaultPrefix,
+	        color:  color.New(color.FgCyan),
+	        printDebug: true,
+	        Flags: defaultFlags,
+	        Outputs: outputs,
+	    },
+		&LoggerT{
+	        Level: LevelDebug, 
+	        Name:   "DEBUG",
+			PrefixName: defaultPrefixName,
+	        Prefix: defaultPrefix,
+	        color:  color.New(color.FgMagenta),
+	        printDebug: true,
+	        Flags: defaultFlags,
+	        Outputs: outputs,
+	    },
+		&LoggerT{
+	        Level: LevelInfo, 
+	        Name:   "INFO",
+			PrefixName: defaultPrefixName,
+	        Prefix: defaultPrefix,
+	        color:  color.New(color.FgBlue),
+	        printDebug: false,
+	        Flags: defaultFlags,
+	        Outputs: outputs,
+	    },
+		&LoggerT{
+	        Level: LevelMsg, 
+	        Name:   "MSG",
+			PrefixName: defaultPrefixName,
+	        Prefix: defaultPrefix,
+	        color:  color.New(color.FgWhite),
+	        printDebug: false,
+	        Flags: defaultFlags,
+	        Outputs: outputs,
+	    },
+		&LoggerT{
+	        Level: LevelWarn,
+	        Name:   "WARN",
+			PrefixName: defaultPrefixName,
+	        Prefix: defaultPrefix,
+	        color:  color.New(color.FgYellow).Add(color.Underline),
+	        printDebug: true,
+	        Flags: defaultFlags,
+	        Outputs: outputs,
+	    },
+		&LoggerT{
+	        Level: LevelError,
+	        Name:   "ERROR",
+			PrefixName: defaultPrefixName,
+	        Prefix: defaultPrefix,
+	        color:  color.New(color.FgRed),
+	        printDebug: true,
+	        Flags: defaultFlags,
+	        Outputs: outputs,
+	    },
+		&LoggerT{
+	        Level: LevelCritical,
+	        Name:   "CRITICAL",
+			PrefixName: defaultPrefixName,
+	        Prefix: defaultPrefix,
+	        color:  color.New(color.FgRed).Add(color.Underline),
+	        printDebug: true,
+	        Flags: defaultFlags,
+	        Outputs: outputs,
+	    },
+		&LoggerT{
+	        Level: LevelFatal,
+	        Name:   "FATAL",
+			PrefixName: defaultPrefixName,
+	        Prefix: defaultPrefix,
+	        color:  color.New(color.FgRed).Add(color.Underline).Add(color.Bold),
+	        printDebug: true,
+	        Flags: defaultFlags,
+	        Outputs: outputs,
+	    },
+	}
+}
+
 //default logset
 var (
-	TRACE = &LoggerT{
-        Level: levelTrace, 
-        Name:   "TRACE",
-		PrefixName: defaultPrefixName,
-        Prefix: defaultPrefix,
-        color:  color.New(color.FgCyan),
-        printDebug: true,
-        Flags: defaultFlags,
-        Outputs: defaultOutputs,
-    }
-	DEBUG = &LoggerT{
-        Level: levelDebug, 
-        Name:   "DEBUG",
-		PrefixName: defaultPrefixName,
-        Prefix: defaultPrefix,
-        color:  color.New(color.FgMagenta),
-        printDebug: true,
-        Flags: defaultFlags,
-        Outputs: defaultOutputs,
-    }
-	INFO = &LoggerT{
-        Level: levelInfo, 
-        Name:   "INFO",
-		PrefixName: defaultPrefixName,
-        Prefix: defaultPrefix,
-        color:  color.New(color.FgBlue),
-        printDebug: false,
-        Flags: defaultFlags,
-        Outputs: defaultOutputs,
-    }
-	MSG = &LoggerT{
-        Level: levelMsg, 
-        Name:   "MSG",
-		PrefixName: defaultPrefixName,
-        Prefix: defaultPrefix,
-        color:  color.New(color.FgWhite),
-        printDebug: false,
-        Flags: defaultFlags,
-        Outputs: defaultOutputs,
-    }
-	WARN = &LoggerT{
-        Level: levelWarn,
-        Name:   "WARN",
-		PrefixName: defaultPrefixName,
-        Prefix: defaultPrefix,
-        color:  color.New(color.FgYellow).Add(color.Underline),
-        printDebug: true,
-        Flags: defaultFlags,
-        Outputs: defaultOutputs,
-    }
-	ERROR = &LoggerT{
-        Level: levelError,
-        Name:   "ERROR",
-		PrefixName: defaultPrefixName,
-        Prefix: defaultPrefix,
-        color:  color.New(color.FgRed),
-        printDebug: true,
-        Flags: defaultFlags,
-        Outputs: defaultOutputs,
-    }
-	CRITICAL = &LoggerT{
-        Level: levelCritical,
-        Name:   "CRITICAL",
-		PrefixName: defaultPrefixName,
-        Prefix: defaultPrefix,
-        color:  color.New(color.FgRed).Add(color.Underline),
-        printDebug: true,
-        Flags: defaultFlags,
-        Outputs: defaultOutputs,
-    }
-	FATAL = &LoggerT{
-        Level: levelFatal,
-        Name:   "FATAL",
-		PrefixName: defaultPrefixName,
-        Prefix: defaultPrefix,
-        color:  color.New(color.FgRed).Add(color.Underline).Add(color.Bold),
-        printDebug: true,
-        Flags: defaultFlags,
-        Outputs: defaultOutputs,
-    }
+	defaultLoggers = newLevelLoggers(defaultOutputs)
+
+	TRACE    = defaultLoggers[0]
+	DEBUG    = defaultLoggers[1]
+	INFO     = defaultLoggers[2]
+	MSG      = defaultLoggers[3]
+	WARN     = defaultLoggers[4]
+	ERROR    = defaultLoggers[5]
+	CRITICAL = defaultLoggers[6]
+	FATAL    = defaultLoggers[7]
 )
 
 func init(){
+	std.TRACE, std.DEBUG, std.INFO, std.MSG = TRACE, DEBUG, INFO, MSG
+	std.WARN, std.ERROR, std.CRITICAL, std.FATAL = WARN, ERROR, CRITICAL, FATAL
 	NewLogger(TRACE,DEBUG,INFO,MSG,WARN,ERROR,CRITICAL,FATAL)
+	loadEnvironment()
 }
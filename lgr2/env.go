@@ -0,0 +1,81 @@
+package lgr
+
+import (
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// the environment variables read when lgr is initialized, each overrides the matching default
+//  LGR_LEVEL         log threshold, ie. DEBUG, in place of defaultLogThreshold
+//  LGR_STDOUT_LEVEL  stdout threshold, in place of defaultStdoutThreshold
+//  LGR_FLAGS         log flags, ie. date,time,shortfile, in place of defaultFlags
+//  LGR_FILE          path of a log file to write to, in addition to the console
+//  LGR_FORMAT        text, logfmt, json or columns, for the console and LGR_FILE
+//  LGR_COLOR         auto, always or never
+const (
+	EnvLevel		= "LGR_LEVEL"
+	EnvStdoutLevel	= "LGR_STDOUT_LEVEL"
+	EnvFlags		= "LGR_FLAGS"
+	EnvFile			= "LGR_FILE"
+	EnvFormat		= "LGR_FORMAT"
+	EnvColor		= "LGR_COLOR"
+)
+
+// loadEnvironment applies the LGR_ environment variables to the default loggers,
+// an invalid value is warned about and the default is kept
+func loadEnvironment() {
+	if value, ok := os.LookupEnv(EnvLevel); ok {
+		if level, err := ParseLevel(value); err != nil {
+			WARN.Printf("%s ignored, keeping %s: %s", EnvLevel, LevelToString(std.logThreshold), err)
+		} else {
+			std.logThreshold = level
+		}
+	}
+	if value, ok := os.LookupEnv(EnvStdoutLevel); ok {
+		if level, err := ParseLevel(value); err != nil {
+			WARN.Printf("%s ignored, keeping %s: %s", EnvStdoutLevel, LevelToString(std.outputThreshold), err)
+		} else {
+			std.outputThreshold = level
+		}
+	}
+	if value, ok := os.LookupEnv(EnvFlags); ok {
+		if flags, err := parseFlags(strings.Split(value, ",")); err != nil {
+			WARN.Printf("%s ignored, keeping the default flags: %s", EnvFlags, err)
+		} else {
+			for _, n := range std.loggers {
+				n.Flags = flags
+			}
+		}
+	}
+	if value, ok := os.LookupEnv(EnvColor); ok {
+		switch strings.ToLower(strings.TrimSpace(value)) {
+			case "", "auto":
+			case "always", "true", "1":
+				color.NoColor = false
+			case "never", "false", "0":
+				color.NoColor = true
+			default:
+				WARN.Printf("%s ignored, keeping auto: unknown value %q, expected auto, always or never", EnvColor, value)
+		}
+	}
+	format := FormatText
+	if value, ok := os.LookupEnv(EnvFormat); ok {
+		var err error
+		if format, err = ParseFormat(value); err != nil {
+			WARN.Printf("%s ignored, keeping text: %s", EnvFormat, err)
+		}
+		stdout.Format = format
+	}
+	if path := os.Getenv(EnvFile); path != "" {
+		output := &FileOutput{Format: format}
+		output.Name = "file"
+		if _, err := output.SetLogFile(path); err != nil {
+			WARN.Printf("%s ignored: %s", EnvFile, err)
+		} else {
+			AddOutput(output)
+			configuredOutputs = append(configuredOutputs, output)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package lgr
+
+import "io"
+import "os"
+import "runtime"
+import "sync/atomic"
+
+// Flusher is implemented by outputs which hold on to records, Flush returns once they have been written
+type Flusher interface {
+	Flush()
+}
+
+var (
+	exitCode		int = 1					// exitCode the process exits with after a FATAL record, guarded by settingsMu
+	fatalHooks		[]func()				// fatalHooks are run before exiting, guarded by settingsMu
+	criticalHooks	[]func(record *Record)	// criticalHooks are run with each CRITICAL record, guarded by settingsMu
+	exiting			int32					// exiting is set by the first FATAL record, later ones do not run the hooks again
+)
+
+// SetExitCode sets the code the process exits with after logging at FATAL, 1 unless set
+func SetExitCode(code int) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	exitCode = code
+}
+
+// OnFatal registers hook to be run when a record is logged at FATAL,
+// once every output has been flushed and before the process exits, see SetExitCode
+// the hooks are run in the order they were registered, ie. to close connections or remove a pid file
+// a hook must not log at FATAL itself, the FATAL logger is still busy with the record
+func OnFatal(hook func()) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	fatalHooks = append(fatalHooks, hook)
+}
+
+// OnCritical registers hook to be run with each record logged at CRITICAL, once it has been written,
+// ie. to page someone or to DumpGoroutines, the process keeps running
+// a hook must not log at CRITICAL itself, the CRITICAL logger is still busy with the record
+func OnCritical(hook func(record *Record)) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	criticalHooks = append(criticalHooks, hook)
+}
+
+// DumpGoroutines returns a hook for OnCritical which writes the stack of every goroutine to writer, ie. os.Stderr
+func DumpGoroutines(writer io.Writer) func(record *Record) {
+	return func(record *Record) {
+		buf := make([]byte, 64<<10)
+		for {
+			n := runtime.Stack(buf, true)
+			if n < len(buf) {
+				writer.Write(buf[:n])
+				return
+			}
+			buf = make([]byte, 2*len(buf))
+		}
+	}
+}
+
+// Flush writes out the records held by any output of the default Instance, see Flusher
+func Flush() {
+	std.Flush()
+}
+
+// Flush writes out the records held by any output of this Instance, see Flusher
+func (inst *Instance) Flush() {
+	settingsMu.RLock()
+	outputs := inst.allOutputs()
+	settingsMu.RUnlock()
+	flushOutputs(outputs)
+}
+
+// flushOutputs flushes each of outputs which is a Flusher
+func flushOutputs(outputs []OutputI) {
+	for _, output := range outputs {
+		if flusher, ok := output.(Flusher); ok {
+			flusher.Flush()
+		}
+	}
+}
+
+// runHooks gives a record its meaning beyond being logged,
+// CRITICAL runs the hooks of OnCritical
+// FATAL flushes the outputs of every Instance, runs the hooks of OnFatal and exits
+func runHooks(record *Record) {
+	switch {
+		case record.Level == LevelCritical:
+			settingsMu.RLock()
+			hooks := criticalHooks
+			settingsMu.RUnlock()
+			for _, hook := range hooks {
+				runHook(func() { hook(record) })
+			}
+		case record.Level >= LevelFatal:
+			if !atomic.CompareAndSwapInt32(&exiting, 0, 1) {
+				return
+			}
+			settingsMu.RLock()
+			outputs := everyOutput()
+			hooks, code := fatalHooks, exitCode
+			settingsMu.RUnlock()
+			flushOutputs(outputs)
+			for _, hook := range hooks {
+				runHook(hook)
+			}
+			os.Exit(code)
+	}
+}
+
+// runHook runs hook, a panic within it is recovered so that the remaining hooks, and the exit, still happen
+func runHook(hook func()) {
+	defer func() {
+		recover()
+	}()
+	hook()
+}
@@ -0,0 +1,81 @@
+package lgr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Allows is true when the record passes every Filter which applies to its level
+func (filters Filters) Allows(record *Record) bool {
+	var text string
+	for _, filter := range filters {
+		if record.Level < Level(filter.Level) || len(filter.Keywords) == 0 && len(filter.Patterns) == 0 {
+			continue
+		}
+		if text == "" {
+			text = filterText(record)
+		}
+		if !filter.matches(text) {
+			return false
+		}
+	}
+	return true
+}
+
+// matches is true when text contains any of the Keywords or matches any of the Patterns
+func (filter Filter) matches(text string) bool {
+	lower := strings.ToLower(text)
+	for _, keyword := range filter.Keywords {
+		if strings.Contains(lower, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	for _, pattern := range filter.Patterns {
+		if pattern.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterText is what keywords are searched for in, the prefixes, message and key=value fields of the record
+func filterText(record *Record) string {
+	var text strings.Builder
+	for _, prefix := range record.Prefix {
+		fmt.Fprint(&text, prefix, " ")
+	}
+	text.WriteString(record.Message)
+	for _, field := range record.Fields {
+		fmt.Fprintf(&text, " %s=%v", field.Key, field.Value)
+	}
+	return text.String()
+}
+
+// SetFilters replaces the filters of this Output, only records passing all of them are written to it
+func (output *Output) SetFilters(filters ...Filter){
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	output.Filters = filters
+}
+
+// Filter lets you add Terms to the Filters of this Output, records at or above level must contain one of the keywords
+func (output *Output) Filter(level Level, keywords ...string) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	output.Filters = append(output.Filters, Filter{Keywords: keywords, Level: int(level)})
+}
+
+// SetFilters replaces the AllowableFilters of the logger, which apply to all of its Outputs
+func (log *LoggerT) SetFilters(filters ...Filter){
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	log.source().AllowableFilters = filters
+}
+
+// Filter lets you add Terms to the AllowableFilters of the logger, records at or above level must contain one of the keywords
+func (log *LoggerT) Filter(level Level, keywords ...string) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	log = log.source()
+	log.AllowableFilters = append(log.AllowableFilters, Filter{Keywords: keywords, Level: int(level)})
+}
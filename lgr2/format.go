@@ -0,0 +1,195 @@
+package lgr
+
+import "bytes"
+import "encoding/json"
+import "fmt"
+import "log"
+import "path/filepath"
+import "strconv"
+import "strings"
+import "time"
+
+// Format selects how an Output, such as ConsoleColorOutput or FileOutput, renders each Record
+type Format int
+
+const (
+	// FormatText is the log package style, <prefix> <NAME>: <date> <time> <file>: message key=value
+	FormatText Format = iota
+	// FormatLogfmt is key=value pairs, time=.. level=.. msg=".." key=value
+	FormatLogfmt
+	// FormatJSON is a single JSON object, see JSONOutput
+	FormatJSON
+	// FormatColumns is fixed width columns, <time> <file> <line> (<pid>,<ppid>) <LEVEL> <function()> message, see ColumnLayout
+	FormatColumns
+)
+
+// render formats the record as a single line, header is only used by FormatText
+func (format Format) render(record *Record, header bool) []byte {
+	switch format {
+		case FormatLogfmt:
+			return formatLogfmt(record)
+		case FormatJSON:
+			return formatJSON(record)
+		case FormatColumns:
+			return formatColumns(record)
+		default:
+			return formatText(record, header)
+	}
+}
+
+// formatHeader writes the date, time and file of the record,
+// as chosen by the log flag constants in record.Flags
+// https://golang.org/pkg/log/#pkg-constants
+func formatHeader(buf *bytes.Buffer, record *Record) {
+	t := record.Time
+	if record.Flags&log.LUTC != 0 {
+		t = t.UTC()
+	}
+	if record.Flags&log.Ldate != 0 {
+		buf.WriteString(t.Format("2006/01/02 "))
+	}
+	if record.Flags&log.Lmicroseconds != 0 {
+		buf.WriteString(t.Format("15:04:05.000000 "))
+	} else if record.Flags&log.Ltime != 0 {
+		buf.WriteString(t.Format("15:04:05 "))
+	}
+	if record.Flags&(log.Lshortfile|log.Llongfile) != 0 {
+		file := record.File
+		if record.Flags&log.Lshortfile != 0 {
+			file = filepath.Base(file)
+		}
+		fmt.Fprintf(buf, "%s:%d: ", file, record.Line)
+	}
+}
+
+// formatText renders the record as a single line of plain text
+// <prefix> <NAME>: <header> message key=value
+// the header is left off when header is false
+// a stack trace follows on indented lines, each frame as its function and then its file:line
+func formatText(record *Record, header bool) []byte {
+	var buf bytes.Buffer
+	for _, prefix := range record.Prefix {
+		fmt.Fprint(&buf, prefix, " ")
+	}
+	if record.PrefixName {
+		buf.WriteString(record.Name + ": ")
+	}
+	if header {
+		formatHeader(&buf, record)
+	}
+	buf.WriteString(record.Message)
+	for _, field := range record.Fields {
+		fmt.Fprintf(&buf, " %s=%v", field.Key, field.Value)
+	}
+	for _, frame := range record.Stack {
+		fmt.Fprintf(&buf, "\n\t%s()\n\t\t%s:%d", frame.Function, frame.File, frame.Line)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// jsonRecord is the layout of a Record in JSON
+type jsonRecord struct {
+	Time     string                     `json:"time"`
+	Name     string                     `json:"level"`
+	Level    Level                      `json:"level_num"`
+	Message  string                     `json:"msg"`
+	File     string                     `json:"file,omitempty"`
+	Line     int                        `json:"line,omitempty"`
+	Function string                     `json:"func,omitempty"`
+	Prefix   []string                   `json:"prefix,omitempty"`
+	Fields   map[string]json.RawMessage `json:"fields,omitempty"`
+	Stack    []StackFrame               `json:"stack,omitempty"`
+}
+
+// formatJSON renders the record as a single JSON object followed by a newline
+// field values which can not be marshalled are written as their fmt.Sprint string
+func formatJSON(record *Record) []byte {
+	out := jsonRecord{
+		Time:     record.Time.Format(time.RFC3339Nano),
+		Name:     record.Name,
+		Level:    record.Level,
+		Message:  record.Message,
+		File:     record.File,
+		Line:     record.Line,
+		Function: record.Function,
+		Stack:    record.Stack,
+	}
+	for _, prefix := range record.Prefix {
+		out.Prefix = append(out.Prefix, fmt.Sprint(prefix))
+	}
+	if len(record.Fields) > 0 {
+		out.Fields = make(map[string]json.RawMessage, len(record.Fields))
+	}
+	for _, field := range record.Fields {
+		value := field.Value
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			raw, _ = json.Marshal(fmt.Sprint(field.Value))
+		}
+		out.Fields[field.Key] = raw
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(out); err != nil {
+		fmt.Fprintf(&buf, "{\"msg\":%q}\n", record.Message)
+	}
+	return buf.Bytes()
+}
+
+// formatLogfmt renders the record as a single line of logfmt
+// time=.. level=ERROR msg=".." caller=file.go:12 func=.. prefix=.. key=value stack="main.f file.go:12; main.main main.go:5"
+func formatLogfmt(record *Record) []byte {
+	var buf bytes.Buffer
+	writeLogfmt(&buf, "time", record.Time.Format(time.RFC3339Nano))
+	writeLogfmt(&buf, "level", record.Name)
+	writeLogfmt(&buf, "msg", record.Message)
+	if record.File != "" {
+		writeLogfmt(&buf, "caller", filepath.Base(record.File)+":"+strconv.Itoa(record.Line))
+	}
+	if record.Function != "" {
+		writeLogfmt(&buf, "func", record.Function)
+	}
+	for _, prefix := range record.Prefix {
+		writeLogfmt(&buf, "prefix", fmt.Sprint(prefix))
+	}
+	for _, field := range record.Fields {
+		writeLogfmt(&buf, field.Key, fmt.Sprint(field.Value))
+	}
+	if len(record.Stack) > 0 {
+		writeLogfmt(&buf, "stack", stackText(record.Stack, "; "))
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// writeLogfmt writes a single key=value pair, separated from any before it by a space
+// characters which would break the pair apart are replaced in the key
+// and the value is quoted when it is empty or holds spaces, quotes, = or control characters
+func writeLogfmt(buf *bytes.Buffer, key string, value string) {
+	if buf.Len() > 0 {
+		buf.WriteByte(' ')
+	}
+	key = strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' || r == 0x7f {
+			return '_'
+		}
+		return r
+	}, key)
+	if key == "" {
+		key = "_"
+	}
+	buf.WriteString(key)
+	buf.WriteByte('=')
+	if value == "" || strings.IndexFunc(value, func(r rune) bool {
+		return r <= ' ' || r == '=' || r == '"' || r == 0x7f || !strconv.IsPrint(r)
+	}) >= 0 {
+		buf.WriteString(strconv.Quote(value))
+		return
+	}
+	buf.WriteString(value)
+}
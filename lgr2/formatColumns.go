@@ -0,0 +1,129 @@
+package lgr
+
+import "bytes"
+import "fmt"
+import "os"
+import "path/filepath"
+import "strconv"
+import "strings"
+import "unicode/utf8"
+
+// ColumnLayout sets the columns of FormatColumns, each is as wide as given, in characters,
+// a shorter value is padded with spaces and a longer one is cut short, marked by …
+// except for the numbers of Line and Pid, which are never cut, but push the rest of the line along
+// a width of 0, or an empty Time, leaves the column out
+//
+//  <time> <filename.ext> <line> (<pid>,<ppid>) <LEVEL> <function()> ****<prefix>**** message key=value
+type ColumnLayout struct {
+	Time		string		`json:"time" yaml:"time" toml:"time"`					// Time is the layout of the time column, see time.Format
+	UTC			bool		`json:"utc" yaml:"utc" toml:"utc"`
+	File		int			`json:"file" yaml:"file" toml:"file"`					// File is cut from the start, as the end of the name says the most
+	Line		int			`json:"line" yaml:"line" toml:"line"`
+	Pid			int			`json:"pid" yaml:"pid" toml:"pid"`						// Pid is the (pid,ppid) column
+	Level		int			`json:"level" yaml:"level" toml:"level"`
+	Function	int			`json:"function" yaml:"function" toml:"function"`		// Function is cut from the start too, it is without its package path
+}
+
+// DefaultColumnLayout is the layout FormatColumns uses until SetColumnLayout
+var DefaultColumnLayout = ColumnLayout{
+	Time:     "2006/01/02 15:04:05",
+	File:     30,
+	Line:     4,
+	Pid:      13,
+	Level:    8,
+	Function: 30,
+}
+
+// columnLayout is the layout of FormatColumns, guarded by settingsMu
+var columnLayout = DefaultColumnLayout
+
+// pid is the process id for the pid column, it does not change, unlike the ppid
+var pid = os.Getpid()
+
+// SetColumnLayout sets the widths of the columns of FormatColumns, for every output
+func SetColumnLayout(layout ColumnLayout) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	columnLayout = layout
+}
+
+// formatColumns renders the record as a line of fixed width columns, see ColumnLayout
+// the file, line and function are those found by getCallerInformation
+// a stack trace follows on indented lines, as in FormatText
+func formatColumns(record *Record) []byte {
+	settingsMu.RLock()
+	layout := columnLayout
+	settingsMu.RUnlock()
+
+	var buf bytes.Buffer
+	if layout.Time != "" {
+		t := record.Time
+		if layout.UTC {
+			t = t.UTC()
+		}
+		buf.WriteString(t.Format(layout.Time) + " ")
+	}
+	writeColumn(&buf, filepath.Base(record.File), layout.File, true)
+	writeNumberColumn(&buf, strconv.Itoa(record.Line), layout.Line)
+	writeNumberColumn(&buf, "("+strconv.Itoa(pid)+","+strconv.Itoa(os.Getppid())+")", layout.Pid)
+	writeColumn(&buf, record.Name, layout.Level, false)
+	function := record.Function
+	if slash := strings.LastIndex(function, "/"); slash >= 0 {
+		function = function[slash+1:]
+	}
+	writeColumn(&buf, function+"()", layout.Function, true)
+	if len(record.Prefix) > 0 {
+		buf.WriteString("****")
+		for i, prefix := range record.Prefix {
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+			fmt.Fprint(&buf, prefix)
+		}
+		buf.WriteString("**** ")
+	}
+	buf.WriteString(record.Message)
+	for _, field := range record.Fields {
+		fmt.Fprintf(&buf, " %s=%v", field.Key, field.Value)
+	}
+	for _, frame := range record.Stack {
+		fmt.Fprintf(&buf, "\n\t%s()\n\t\t%s:%d", frame.Function, frame.File, frame.Line)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+// writeColumn writes value padded, or cut, to width characters, followed by a space
+// keepEnd cuts a long value from the start rather than the end
+func writeColumn(buf *bytes.Buffer, value string, width int, keepEnd bool) {
+	if width <= 0 {
+		return
+	}
+	length := utf8.RuneCountInString(value)
+	if length > width {
+		runes := []rune(value)
+		if width == 1 {
+			value = "…"
+		} else if keepEnd {
+			value = "…" + string(runes[length-width+1:])
+		} else {
+			value = string(runes[:width-1]) + "…"
+		}
+		length = width
+	}
+	buf.WriteString(value)
+	buf.WriteString(strings.Repeat(" ", width-length+1))
+}
+
+// writeNumberColumn writes value padded to width characters, followed by a space
+// a longer value is written whole, as part of a number is of no use
+func writeNumberColumn(buf *bytes.Buffer, value string, width int) {
+	if width <= 0 {
+		return
+	}
+	buf.WriteString(value)
+	if length := utf8.RuneCountInString(value); length < width {
+		buf.WriteString(strings.Repeat(" ", width-length))
+	}
+	buf.WriteByte(' ')
+}
@@ -0,0 +1,38 @@
+package lgr
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFormatColumns(t *testing.T) {
+	settingsMu.Lock()
+	saved := columnLayout
+	columnLayout = ColumnLayout{Time: "15:04:05", File: 6, Line: 3, Pid: 4, Level: 5, Function: 8}
+	settingsMu.Unlock()
+	defer SetColumnLayout(saved)
+
+	record := &Record{
+		Time:     time.Date(2024, 3, 5, 7, 8, 9, 0, time.UTC),
+		Name:     "CRITICAL",
+		Message:  "disk full",
+		File:     "/src/app/server.go",
+		Line:     12345,
+		Function: "github.com/x/app.(*Server).Serve",
+		Fields:   Fields{{Key: "path", Value: "/var"}},
+	}
+	pids := "(" + strconv.Itoa(os.Getpid()) + "," + strconv.Itoa(os.Getppid()) + ")"
+	// the file and function keep their end, the level its start, the line and pid are never cut
+	want := "07:08:09 …er.go 12345 " + pids + " CRIT… …Serve() disk full path=/var\n"
+	if got := string(formatColumns(record)); got != want {
+		t.Errorf("formatColumns = %q, expected %q", got, want)
+	}
+
+	record.Line, record.Name, record.File = 7, "INFO", "a.go"
+	want = "07:08:09 a.go   7   " + pids + " INFO  …Serve() disk full path=/var\n"
+	if got := string(formatColumns(record)); got != want {
+		t.Errorf("formatColumns = %q, expected %q", got, want)
+	}
+}
@@ -0,0 +1,154 @@
+package lgr
+
+// Instance is an independent set of loggers, TRACE to FATAL,
+// with thresholds and outputs of its own, so that two libraries in one binary, or parallel tests,
+// do not change each others logging
+// the package level functions, and TRACE to FATAL, are those of the default Instance
+// configuration files, the LGR_ environment variables and the AdminHandler apply to the default Instance,
+// level overrides, see SetLevelOverrides, Reopen and the flush before exiting on FATAL apply to every Instance,
+// until it is closed, see Close
+type Instance struct {
+	TRACE			*LoggerT
+	DEBUG			*LoggerT
+	INFO			*LoggerT
+	MSG				*LoggerT
+	WARN			*LoggerT
+	ERROR			*LoggerT
+	CRITICAL		*LoggerT
+	FATAL			*LoggerT
+	logThreshold	Level				// logThreshold is used by the outputs without a threshold of their own
+	outputThreshold	Level				// outputThreshold is used by the console output created by New
+	loggers			[]*LoggerT			// loggers are all of the LoggerT which have been readied with NewLogger, guarded by settingsMu
+	parent			*Instance			// parent is the Instance this one was derived from by With, its thresholds are used
+}
+
+// std is the default Instance, its loggers are set up in init
+var std = &Instance{
+	logThreshold:    defaultLogThreshold,
+	outputThreshold: defaultStdoutThreshold,
+}
+
+// instances are the default Instance and every Instance created by New, guarded by settingsMu
+var instances = []*Instance{std}
+
+// everyOutput returns the outputs of every Instance, each once, the caller must hold settingsMu
+func everyOutput() []OutputI {
+	var outputs []OutputI
+	seen := make(map[*Output]bool)
+	for _, inst := range instances {
+		for _, output := range inst.allOutputs() {
+			if !seen[output.GetOutput()] {
+				seen[output.GetOutput()] = true
+				outputs = append(outputs, output)
+			}
+		}
+	}
+	return outputs
+}
+
+// New returns an Instance with the default thresholds whose loggers write to outputs,
+// or to a console output of its own, following its StdoutThreshold, when there are none
+func New(outputs ...OutputI) *Instance {
+	inst := &Instance{
+		logThreshold:    defaultLogThreshold,
+		outputThreshold: defaultStdoutThreshold,
+	}
+	if len(outputs) == 0 {
+		console := NewConsoleColorOutput()
+		console.outputThreshold = &inst.outputThreshold
+		console.followed = console.outputThreshold
+		outputs = []OutputI{console}
+	}
+	loggers := newLevelLoggers(outputs)
+	inst.TRACE, inst.DEBUG, inst.INFO, inst.MSG = loggers[0], loggers[1], loggers[2], loggers[3]
+	inst.WARN, inst.ERROR, inst.CRITICAL, inst.FATAL = loggers[4], loggers[5], loggers[6], loggers[7]
+	inst.NewLogger(loggers...)
+	settingsMu.Lock()
+	instances = append(instances, inst)
+	settingsMu.Unlock()
+	return inst
+}
+
+// Close removes the Instance from those Reopen and the flush before exiting on FATAL reach,
+// and closes those of its outputs which hold a file or connection, unless another Instance still writes to them
+// its loggers must not be used afterwards
+// the default Instance, and an Instance derived by With, which shares the outputs it came from, are left as they are
+func (inst *Instance) Close() {
+	if inst == std || inst.parent != nil {
+		return
+	}
+	settingsMu.Lock()
+	for i, registered := range instances {
+		if registered == inst {
+			instances = append(instances[:i:i], instances[i+1:]...)
+			break
+		}
+	}
+	inUse := make(map[*Output]bool)
+	for _, output := range everyOutput() {
+		inUse[output.GetOutput()] = true
+	}
+	var closing []OutputI
+	for _, output := range inst.allOutputs() {
+		if !inUse[output.GetOutput()] {
+			closing = append(closing, output)
+		}
+	}
+	settingsMu.Unlock()
+	for _, output := range closing {
+		closeOutput(output)
+	}
+}
+
+// With returns a copy of the Instance whose loggers add the key/value pairs to every line
+// ie. requestLog := lgr.New().With("request", id)
+// the copy follows the thresholds of the Instance it came from, changing them on either changes both
+func (inst *Instance) With(keyvals ...interface{}) *Instance {
+	return inst.withFields(Fields(nil).With(keyvals...))
+}
+
+// withFields returns a copy of the Instance whose loggers add fields to every line
+func (inst *Instance) withFields(fields Fields) *Instance {
+	settingsMu.RLock()
+	loggers := inst.loggers
+	settingsMu.RUnlock()
+	derived := &Instance{parent: inst.base()}
+	copies := make(map[*LoggerT]*LoggerT, len(loggers))
+	for _, n := range loggers {
+		copies[n] = n.withFields(fields)
+		derived.loggers = append(derived.loggers, copies[n])
+	}
+	derived.TRACE, derived.DEBUG, derived.INFO, derived.MSG = copies[inst.TRACE], copies[inst.DEBUG], copies[inst.INFO], copies[inst.MSG]
+	derived.WARN, derived.ERROR, derived.CRITICAL, derived.FATAL = copies[inst.WARN], copies[inst.ERROR], copies[inst.CRITICAL], copies[inst.FATAL]
+	return derived
+}
+
+// base is the Instance whose thresholds this one follows, itself unless it was derived by With
+func (inst *Instance) base() *Instance {
+	if inst.parent != nil {
+		return inst.parent
+	}
+	return inst
+}
+
+// levelLogger returns the logger of level
+func (inst *Instance) levelLogger(level Level) *LoggerT {
+	switch levelCheck(level) {
+		case LevelTrace:
+			return inst.TRACE
+		case LevelDebug:
+			return inst.DEBUG
+		case LevelInfo:
+			return inst.INFO
+		case LevelMsg:
+			return inst.MSG
+		case LevelWarn:
+			return inst.WARN
+		case LevelError:
+			return inst.ERROR
+		case LevelCritical:
+			return inst.CRITICAL
+		default:
+			return inst.FATAL
+	}
+}
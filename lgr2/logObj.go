@@ -3,6 +3,9 @@ package lgr
 
 import "io"
 import "log"
+import "regexp"
+import "strings"
+import "time"
 
 import "github.com/fatih/color"
 
@@ -23,115 +26,252 @@ type LoggerT struct {
 	Outputs          []OutputI
 	AllowableFilters Filters
 	HighlightFilters Filters
+	Fields           Fields					// Fields are added to every Record, see With
+	instance         *Instance				// instance is the Instance the logger was readied in by NewLogger
+	parent           *LoggerT				// parent is the logger this one was derived from by With, whose settings it uses
 }
 
 type PrefixList []interface{}
 
+// Filters only allow a Record through when it passes every Filter
 type Filters []Filter
 
+// Filter is passed by a Record containing any one of the Keywords, ignoring case, or matching any of the Patterns
+// it only applies to records at or above its Level, those below always pass
+// as one of the HighlightFilters, the matches are shown on the console in Style
 type Filter struct {
 	Keywords	[]string
+	Patterns	[]*regexp.Regexp
 	Level		int
+	Style		*color.Color				// Style of highlighted matches, bold when nil
 }
 
 type Log map[*log.Logger]*LoggerT
 
+// NewLogger readies each LoggerT for use,
+// its embedded log.Logger writes to the LoggerT itself, which passes each message on to the Outputs
+func NewLogger(loggerList ...*LoggerT){
+	std.NewLogger(loggerList...)
+}
+
+// NewLogger readies each LoggerT for use within this Instance,
+// it follows the thresholds and is changed by the settings of the Instance
+func (inst *Instance) NewLogger(loggerList ...*LoggerT){
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	for _, n := range loggerList {
+		n.Logger = log.New(n, "", 0)
+		n.instance = inst
+		inst.loggers = append(inst.loggers, n)
+	}
+}
 
-func NewLog(log ...LogT){
-	for _, n := range log {
-
-		for _, output := range n.Outputs {
-			// if the log level is less then the outputThreshold (stdout)
-			// and less than LoggerThreshold (file output)
-			// then don't log anything
-			if n.Level < outputThreshold && n.Level < LoggerThreshold {
-				n.Handle = ioutil.Discard
-			} else if n.Level >= outputThreshold && n.Level >= LoggerThreshold {
-				// if greater than or equal to both, log to both
-				n.Handle = io.MultiWriter(FileHandle, n)
-			} else if n.Level >= outputThreshold && n.Level < LoggerThreshold {
-				// if only outputThreshold is greater, only log to console
-				n.Handle = n
-			} else {
-				// else (the only option remaining is LoggerThreshold is greater)
-				// log to FileLogger only
-				n.Handle = FileHandle
+// loggerNamed returns the logger readied with NewLogger which has name, ignoring case, or nil
+func (inst *Instance) loggerNamed(name string) *LoggerT {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+	var logger *LoggerT
+	for _, n := range inst.loggers {
+		if strings.EqualFold(n.Name, name) {
+			logger = n
+		}
+	}
+	return logger
+}
+
+// Write receives each message from the embedded log.Logger
+// and hands it to every one of the Outputs as a Record
+func (log *LoggerT) Write(p []byte) (n int, err error) {
+	record := log.newRecord(strings.TrimSuffix(string(p), "\n"))
+	return len(p), log.dispatch(record)
+}
+
+// dispatch writes the record to each of the Outputs whose threshold it meets
+// the first error an Output returns is returned
+// a level override for the caller, see SetLevelOverrides, is used in place of the thresholds
+// the record must pass the AllowableFilters of the logger and the Filters of each Output
+// the outputs are chosen while holding settingsMu, then written to without it
+// a CRITICAL or FATAL record then runs its hooks, see OnCritical and OnFatal, whether it was written or not
+func (log *LoggerT) dispatch(record *Record) (err error) {
+	override := overrideLevel(record.File, record.Function)
+	var outputs []OutputI
+	settingsMu.RLock()
+	source := log.source()
+	if source.AllowableFilters.Allows(record) {
+		for _, output := range source.Outputs {
+			if override != nil && record.Level < *override || override == nil && record.Level < output.GetOutput().threshold(log.logThreshold()) {
+				continue
 			}
+			if !output.GetOutput().Filters.Allows(record) {
+				continue
+			}
+			outputs = append(outputs, output)
+		}
+	}
+	settingsMu.RUnlock()
+	for _, output := range outputs {
+		if outputErr := output.WriteRecord(record); outputErr != nil && err == nil {
+			err = outputErr
+		}
+	}
+	runHooks(record)
+	return err
+}
 
-			*n.Logger = log.New(n.Handle, n.Prefix, n.Flags)
+// enabled is true when at least one of the Outputs would write a Record of this logger,
+// from some caller, as the overrides are considered too
+func (log *LoggerT) enabled() bool {
+	lowest := lowestOverride()
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+	outputs := log.source().Outputs
+	if lowest != nil && len(outputs) > 0 && log.Level >= *lowest {
+		return true
+	}
+	for _, output := range outputs {
+		if log.Level >= output.GetOutput().threshold(log.logThreshold()) {
+			return true
 		}
 	}
+	return false
+}
+
+// source is the logger whose settings this one uses, the one it was derived from by With, or else itself
+func (log *LoggerT) source() *LoggerT {
+	if log.parent != nil {
+		return log.parent
+	}
+	return log
+}
+
+// logThreshold is the log threshold of the Instance of the logger, the caller must hold settingsMu
+// a logger which was not readied with NewLogger follows the default Instance
+func (log *LoggerT) logThreshold() Level {
+	log = log.source()
+	if log.instance == nil {
+		return std.logThreshold
+	}
+	return log.instance.base().logThreshold
+}
+
+// newRecord builds the Record for message as logged from outside of lgr
+func (log *LoggerT) newRecord(message string) *Record {
+	fileName, lineNumber, callerName, stack, _ := getCallerInformation(log.Level)
+	record := log.newRecordAt(message, fileName, lineNumber, callerName)
+	record.Stack = stack
+	return record
+}
+
+// newRecordAt builds the Record for message as logged from the given point in code
+// a logger derived by With adds its fields to the settings, as they are now, of the logger it came from
+func (log *LoggerT) newRecordAt(message string, fileName string, lineNumber int, callerName string) *Record {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+	source := log.source()
+	fields := log.Fields
+	if source != log {
+		fields = append(source.Fields[:len(source.Fields):len(source.Fields)], log.Fields...)
+	}
+	return &Record{
+		Time:       time.Now(),
+		Level:      source.Level,
+		Name:       source.Name,
+		Message:    message,
+		Prefix:     source.Prefix,
+		PrefixName: source.PrefixName,
+		Fields:     fields,
+		Flags:      source.Flags,
+		File:       fileName,
+		Line:       lineNumber,
+		Function:   callerName,
+		color:      source.color,
+		printDebug: source.printDebug,
+		highlights: source.HighlightFilters,
+	}
+}
+
+// With returns a copy of the logger which adds the key/value pairs to every line it emits
+// ie. lgr.ERROR.With("request", id, "user", user).Println("I've stubbed my toe")
+// each Output renders the fields in its own way
+// the copy keeps following the outputs, prefix, flags and filters of the logger it came from, changing them on either changes both
+func (logger *LoggerT) With(keyvals ...interface{}) *LoggerT {
+	return logger.withFields(Fields(nil).With(keyvals...))
+}
+
+// withFields returns a copy of the logger which adds fields, after its own, to every line
+// the copy only holds the fields it adds, the rest is read from its parent as each line is logged
+func (logger *LoggerT) withFields(fields Fields) *LoggerT {
+	settingsMu.RLock()
+	derived := *logger
+	settingsMu.RUnlock()
+	derived.parent = logger.source()
+	if logger.parent == nil {
+		derived.Fields = nil
+	}
+	derived.Fields = append(derived.Fields[:len(derived.Fields):len(derived.Fields)], fields...)
+	derived.Logger = log.New(&derived, "", 0)
+	return &derived
+}
+
+// AddOutput adds the outputs to ALL logs in lgr.
+func AddOutput(outputs ...OutputI){
+	std.AddOutput(outputs...)
+}
+
+// AddOutput adds the outputs to all of the loggers of this Instance
+func (inst *Instance) AddOutput(outputs ...OutputI){
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	for _, n := range inst.loggers {
+		n = n.source()
+		n.Outputs = append(n.Outputs[:len(n.Outputs):len(n.Outputs)], outputs...)
+	}
 }
-		
 
 // SetPrefix allows for changing the prefixes of ALL logs in lgr.
 func SetPrefix(prefix string){
-	for _, n := range LoggerTs {
-        n.Prefix = prefix
-    }
-	refreshLoggerTs()
-    INFO.Printf("NewPrefix(%+v)",prefix)
+	std.SetPrefix(prefix)
+}
+
+// SetPrefix changes the prefixes of all of the loggers of this Instance
+func (inst *Instance) SetPrefix(prefix string){
+	settingsMu.Lock()
+	for _, n := range inst.loggers {
+		n.source().Prefix = PrefixList{prefix}
+	}
+	settingsMu.Unlock()
+	inst.INFO.Printf("NewPrefix(%+v)",prefix)
 }
-//                             50c  |                       |        |                    |
-//<time> <filename.ext>(30char, left) line ##(4 char, left) (pid,ppid)MESSAGE_TYPE(8, left) function() ?****EVENT**** message ?Value
 
 // SetPrefix allows for changing the prefix of a specific log.
 func (log *LoggerT) SetPrefix(prefix string){
-    log.Prefix = prefix
-    refreshLoggerTs()
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	log.source().Prefix = PrefixList{prefix}
 }
 
-// AppendPrefix allows for appending to the prefixes of ALL lgr logs 
+// AppendPrefix allows for appending to the prefixes of ALL lgr logs
 func AppendPrefix(prefix string){
-	for _, n := range LoggerTs {
-        n.Prefix = prefix + n.Prefix
-    }
-	refreshLoggerTs()
-    INFO.Printf("NewPrefix(%+v)",prefix)
+	std.AppendPrefix(prefix)
 }
 
-// AppendPrefix allows for appending to the prefix of a specific log.
-func (log *LoggerT) AppendPrefix(prefix string){
-    log.Prefix = prefix + log.Prefix
-    refreshLoggerTs()
-}
-
-//Filter lets you add Terms to the Filter
-func (log *lgr) Filter() {
-
-}
-
-func refreshLoggerTs(){
-	// see log flag constants
-	// https://golang.org/pkg/log/#pkg-constants
-	for _, n := range LoggerTs {
-				
-				// if the log level is less than the outputThreshold (stdout)
-				// and less than LoggerThreshold (file output)
-				// than don't log anything
-		if n.Level < outputThreshold && n.Level < LoggerThreshold {
-			n.Handle = ioutil.Discard
-		} else if n.Level >= outputThreshold && n.Level >= LoggerThreshold {
-			// if greater than or equal to both, log to both
-			n.Handle = io.MultiWriter(FileHandle, n)
-		} else if n.Level >= outputThreshold && n.Level < LoggerThreshold {
-			// if only outputThreshold is greater, only log to console
-			n.Handle = n
-		} else {
-			// else (the only option remaining is LoggerThreshold is greater)
-			// log to FileLogger only
-			n.Handle = FileHandle
-		}
-		*n.Logger = log.New(n.Handle, n.Prefix, n.Flags)
+// AppendPrefix appends to the prefixes of all of the loggers of this Instance
+func (inst *Instance) AppendPrefix(prefix string){
+	settingsMu.Lock()
+	for _, n := range inst.loggers {
+		n = n.source()
+		n.Prefix = append(PrefixList{prefix}, n.Prefix...)
 	}
+	settingsMu.Unlock()
+	inst.INFO.Printf("NewPrefix(%+v)",prefix)
 }
 
-
-func New() (logger LoggerT) {
-
-
-
-	// logger.Writer = 
+// AppendPrefix allows for appending to the prefix of a specific log.
+func (log *LoggerT) AppendPrefix(prefix string){
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	log = log.source()
+	log.Prefix = append(PrefixList{prefix}, log.Prefix...)
 }
 
 
@@ -2,61 +2,146 @@ package lgr
 
 import "runtime"
 import "errors"
-import "log"
+import "strings"
 
 type Output struct {
 	Name			string
 	Filters			Filters
-	outputThreshold	Level
+	outputThreshold	*Level				// outputThreshold points at the threshold of an Instance until SetOutputThreshold is used, nil is the log threshold
+	followed		*Level				// followed is the threshold of an Instance which outputThreshold first pointed at, see ResetOutputThreshold
 }
 
+// OutputI is implemented by everything a LoggerT can write to,
+// every kind of output embeds Output, which satisfies GetOutput
 type OutputI interface {
-	Writer(p []byte) (n int, err error)
+	WriteRecord(record *Record) error
+	GetOutput() *Output
+}
+
+// GetOutput returns the settings shared by every kind of output
+func (output *Output) GetOutput() *Output {
+	return output
+}
+
+// Threshold returns the level a Record must match or exceed to be written to this output
+// an output without a threshold of its own follows the log threshold, this is that of the default Instance
+func (output *Output) Threshold() Level {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+	return output.threshold(std.logThreshold)
+}
+
+// threshold is Threshold for callers already holding settingsMu,
+// logThreshold is that of the Instance of the logger writing to this output
+func (output *Output) threshold(logThreshold Level) Level {
+	if output.outputThreshold == nil {
+		return logThreshold
+	}
+	return *output.outputThreshold
+}
+
+// lgrPackage is the import path of lgr, frames within it are skipped when looking for the caller
+var lgrPackage = callerPackage()
+
+// callerPackage returns the import path of the package it is declared in
+func callerPackage() string {
+	pc, _, _, _ := runtime.Caller(0)
+	name := runtime.FuncForPC(pc).Name()
+	return name[:strings.LastIndex(name, ".")]
+}
+
+// isInternalFrame is true when function belongs to lgr or to the log packages it is built upon
+func isInternalFrame(function string) bool {
+	return strings.HasPrefix(function, lgrPackage+".") || strings.HasPrefix(function, "log.") || strings.HasPrefix(function, "log/slog.")
 }
 
 // getCallerInformation retrieves information about the point in code which logged this message
 // callerName is a string containing the calling functions name
 // this will be printed in the log message
-func getCallerInformation() (fileName string, lineNumber int, callerName string, err error) {
+// for a message at or above the stack level, see SetStackLevel, the stack from the caller outwards is returned too
+func getCallerInformation(level Level) (fileName string, lineNumber int, callerName string, stack []StackFrame, err error) {
+	depth, filters := stackOptions(level)
+	return getCallerStack(depth, filters)
+}
+
+// getCallerStack is getCallerInformation with a stack of up to depth frames, without those matching filters
+func getCallerStack(depth int, filters []string) (fileName string, lineNumber int, callerName string, stack []StackFrame, err error) {
 	// incase we encounter some panic here, let's try to exit with grace
 	defer func(){
 		if r := recover(); r != nil {
 			fileName = ""
 			lineNumber = 0
 			callerName = ""
+			stack = nil
 			err = errors.New("Error while trying to discover caller information, 1 or more lines may be missing from the log.")
 		}
 	}()
-		
+
 	// lvl is the number of levels to go up the call tree
+	// the first frames are lgr and log, these are skipped below
 	var lvl int = 2
 
 	// get function http://moazzam-khan.com/blog/golang-get-the-function-callers-name/
 	// get calling function
 	// callStack is an array of calling entities
-	callStack := make([]uintptr, 1)
-	// Skip 2 levels to get the caller
-	if runtime.Callers(lvl, callStack) == 0 {
-			// No caller found
-			callerName = "****NOT*FOUND****"
-	}
+	callStack := make([]uintptr, 32+depth)
+	count := runtime.Callers(lvl, callStack)
 
-	caller := runtime.FuncForPC(callStack[0]-1)
-	if caller == nil {
-			// caller was nil
-			callerName = "nil"
+	// the caller is the first frame outside of lgr
+	// when lgr logs for itself, ie. from its own goroutines, the outermost frame of lgr is used
+	// the stack follows from the caller, without the frames of lgr or those filtered, up to depth frames
+	// https://golang.org/pkg/runtime/#Frames
+	var fallback runtime.Frame
+	var found bool
+	frames := runtime.CallersFrames(callStack[:count])
+	for count > 0 {
+		frame, more := frames.Next()
+		if found {
+			if len(stack) >= depth {
+				break
+			}
+			if !isInternalFrame(frame.Function) && !isFilteredFrame(frame.Function, filters) {
+				stack = append(stack, StackFrame{Function: frame.Function, File: frame.File, Line: frame.Line})
+			}
+		} else if strings.HasPrefix(frame.Function, lgrPackage+".") {
+			fallback = frame
+		} else if !isInternalFrame(frame.Function) && !strings.HasPrefix(frame.Function, "runtime.") {
+			fileName, lineNumber, callerName, found = frame.File, frame.Line, frame.Function, true
+			if depth == 0 {
+				break
+			}
+			if !isFilteredFrame(frame.Function, filters) {
+				stack = append(stack, StackFrame{Function: frame.Function, File: frame.File, Line: frame.Line})
+			}
+		}
+		if !more {
+			break
+		}
+	}
+	if found {
+		return fileName, lineNumber, callerName, stack, nil
+	}
+	if fallback.Function != "" {
+		return fallback.File, fallback.Line, fallback.Function, nil, nil
 	}
 
-	// Print the file name and line number
-	// https://golang.org/pkg/runtime/#Func.FileLine
-	fileName, lineNumber = caller.FileLine(callStack[0]-1)
-
-	// Print the name of the function
-	callerName = caller.Name()
+	// No caller found
+	callerName = "****NOT*FOUND****"
+	return fileName, lineNumber, callerName, nil, nil
+}
 
-	return fileName, lineNumber, callerName, nil
+// SetOutputThreshold Establishes a threshold where anything matching or above will be written to this output
+func (output *Output) SetOutputThreshold(level Level){
+	level = levelCheck(level)
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	output.outputThreshold = &level
 }
 
-func (output *Output) SetOutputThreshold(level *log.Logger){
-	output.outputThreshold = level.
+// ResetOutputThreshold undoes SetOutputThreshold, the output follows the threshold it did at first again,
+// the stdout threshold of its Instance for a console output, otherwise the log threshold
+func (output *Output) ResetOutputThreshold(){
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	output.outputThreshold = output.followed
 }
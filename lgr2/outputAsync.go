@@ -0,0 +1,201 @@
+package lgr
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OverflowPolicy is what an AsyncOutput does with a Record when its queue is full
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for room in the queue
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropNewest discards the Record being written
+	OverflowDropNewest
+	// OverflowDropOldest discards the oldest queued Record to make room
+	OverflowDropOldest
+)
+
+// DefaultReportInterval is how often an AsyncOutput logs the records it has dropped
+var DefaultReportInterval = time.Minute
+
+// AsyncOutput queues records for another Output, which a background goroutine writes them to,
+// so that logging does not wait on a slow file or console
+// its threshold and filters are those of the Output it wraps
+type AsyncOutput struct {
+	output		OutputI
+	queue		chan asyncItem
+	policy		OverflowPolicy
+	dropped		uint64			// dropped is the count of discarded records, read with Dropped
+	mu			sync.RWMutex	// mu is held, shared, while queueing, so that nothing is queued once Close has begun
+	closed		bool			// closed is set by Close, guarded by mu, later records are written directly
+	done		chan struct{}	// done is closed by Close to stop the background goroutines
+	drained		chan struct{}	// drained is closed by drain once it has written the last queued record
+}
+
+// asyncItem is either a Record to write or, when flushed is set, a request to report back once reached
+type asyncItem struct {
+	record		*Record
+	flushed		chan struct{}
+}
+
+// NewAsyncOutput wraps output in a queue holding up to size records, at least 1,
+// policy decides what happens once it is full.
+// When records are dropped, the count is logged to WARN every DefaultReportInterval
+func NewAsyncOutput(output OutputI, size int, policy OverflowPolicy) *AsyncOutput {
+	// an unbuffered queue is never full, so nothing would be dropped, each write would wait on the background goroutine
+	if size < 1 {
+		size = 1
+	}
+	async := &AsyncOutput{
+		output:  output,
+		queue:   make(chan asyncItem, size),
+		policy:  policy,
+		done:    make(chan struct{}),
+		drained: make(chan struct{}),
+	}
+	go async.drain()
+	if policy != OverflowBlock {
+		go async.report(DefaultReportInterval)
+	}
+	return async
+}
+
+// GetOutput returns the settings of the wrapped Output
+func (async *AsyncOutput) GetOutput() *Output {
+	return async.output.GetOutput()
+}
+
+// WriteRecord queues the record, following the OverflowPolicy when the queue is full
+// once the AsyncOutput is closed records are written directly
+func (async *AsyncOutput) WriteRecord(record *Record) error {
+	async.mu.RLock()
+	defer async.mu.RUnlock()
+	if async.closed {
+		return async.output.WriteRecord(record)
+	}
+	item := asyncItem{record: record}
+	switch async.policy {
+		case OverflowDropNewest:
+			select {
+				case async.queue <- item:
+				default:
+					atomic.AddUint64(&async.dropped, 1)
+			}
+		case OverflowDropOldest:
+			// a Flush waits on its marker, so one taken off the queue to make room is queued again after the record
+			var markers []asyncItem
+			for {
+				select {
+					case async.queue <- item:
+						if len(markers) == 0 {
+							return nil
+						}
+						item, markers = markers[0], markers[1:]
+						continue
+					default:
+				}
+				select {
+					case oldest := <-async.queue:
+						if oldest.flushed != nil {
+							markers = append(markers, oldest)
+						} else {
+							atomic.AddUint64(&async.dropped, 1)
+						}
+					default:
+				}
+			}
+		default:
+			async.queue <- item
+	}
+	return nil
+}
+
+// Dropped returns the number of records discarded because the queue was full
+func (async *AsyncOutput) Dropped() uint64 {
+	return atomic.LoadUint64(&async.dropped)
+}
+
+// Flush waits until every record queued before it has been written,
+// then flushes the wrapped Output when it is a Flusher
+func (async *AsyncOutput) Flush() {
+	async.mu.RLock()
+	if !async.closed {
+		flushed := make(chan struct{})
+		async.queue <- asyncItem{flushed: flushed}
+		async.mu.RUnlock()
+		<-flushed
+	} else {
+		async.mu.RUnlock()
+	}
+	if flusher, ok := async.output.(Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Close writes out the queue, stops the background goroutines and flushes the wrapped Output when it is a Flusher
+func (async *AsyncOutput) Close() {
+	async.mu.Lock()
+	if async.closed {
+		async.mu.Unlock()
+		return
+	}
+	async.closed = true
+	close(async.done)
+	async.mu.Unlock()
+	<-async.drained
+	if flusher, ok := async.output.(Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// drain writes each queued record to the wrapped Output, until Close, when it empties the queue and returns
+func (async *AsyncOutput) drain() {
+	defer close(async.drained)
+	for {
+		select {
+			case item := <-async.queue:
+				async.write(item)
+			case <-async.done:
+				// nothing more is queued once done is closed
+				for {
+					select {
+						case item := <-async.queue:
+							async.write(item)
+						default:
+							return
+					}
+				}
+		}
+	}
+}
+
+// write writes the record of item to the wrapped Output, or reports back to the Flush which queued it
+func (async *AsyncOutput) write(item asyncItem) {
+	if item.flushed != nil {
+		close(item.flushed)
+		return
+	}
+	async.output.WriteRecord(item.record)
+}
+
+// report logs the count of dropped records every interval, when it has grown
+func (async *AsyncOutput) report(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var reported uint64
+	for {
+		select {
+			case <-ticker.C:
+				dropped := async.Dropped()
+				if dropped != reported {
+					WARN.Printf("AsyncOutput(%s) dropped %d records, %d in total", async.GetOutput().Name, dropped-reported, dropped)
+					reported = dropped
+				}
+			case <-async.done:
+				return
+		}
+	}
+}
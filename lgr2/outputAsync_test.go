@@ -0,0 +1,82 @@
+package lgr
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowOutput keeps the messages of the records written to it, taking a while over each
+type slowOutput struct {
+	Output
+	mu			sync.Mutex
+	messages	[]string
+}
+
+func (output *slowOutput) WriteRecord(record *Record) error {
+	time.Sleep(100 * time.Microsecond)
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	output.messages = append(output.messages, record.Message)
+	return nil
+}
+
+// written returns the number of records written to the output
+func (output *slowOutput) written() int {
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	return len(output.messages)
+}
+
+// TestAsyncOutputDropOldestFlush flushes while a full queue is dropping its oldest records, no Flush may be left waiting
+func TestAsyncOutputDropOldestFlush(t *testing.T) {
+	async := NewAsyncOutput(&slowOutput{}, 1, OverflowDropOldest)
+	defer async.Close()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		var writers sync.WaitGroup
+		for w := 0; w < 4; w++ {
+			writers.Add(1)
+			go func() {
+				defer writers.Done()
+				for i := 0; i < 500; i++ {
+					async.WriteRecord(&Record{Message: strconv.Itoa(i)})
+				}
+			}()
+		}
+		for i := 0; i < 100; i++ {
+			async.Flush()
+		}
+		writers.Wait()
+	}()
+	select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatal("Flush is still waiting")
+	}
+}
+
+// TestAsyncOutputClose closes with records still queued, every one of them must be written
+func TestAsyncOutputClose(t *testing.T) {
+	output := &slowOutput{}
+	async := NewAsyncOutput(output, 100, OverflowBlock)
+	var writers sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		writers.Add(1)
+		go func() {
+			defer writers.Done()
+			for i := 0; i < 50; i++ {
+				async.WriteRecord(&Record{Message: strconv.Itoa(i)})
+			}
+		}()
+	}
+	// some are queued before Close, some after, which are written directly
+	time.Sleep(time.Millisecond)
+	async.Close()
+	writers.Wait()
+	if written := output.written(); written != 200 {
+		t.Errorf("%d records written, expected 200", written)
+	}
+}
@@ -1,56 +1,182 @@
 package lgr
 
 import "io"
+import "regexp"
+import "sort"
+import "strings"
+import "sync"
+import "unicode"
+import "unicode/utf8"
 
 import "github.com/fatih/color"
 
 
+// consoleMu serializes the writes of every ConsoleColorOutput, as they usually share the console
+var consoleMu sync.Mutex
 
 type ConsoleColorOutput struct {
 	Output
-	color									*color.Color
+	color									*color.Color			// color overrides the color of the LoggerT when set
+	writer									io.Writer
+	Format									Format
+	HighlightFilters						Filters					// HighlightFilters are shown for every logger, as well as their own
 }
 
-// ConsoleColorWriter acts as a modifier pre-output for the logs.
+// NewConsoleColorOutput returns an Output writing colorized text to the console (stdout)
+// it follows the stdout threshold of the default Instance, see SetStdoutThreshold
+func NewConsoleColorOutput() *ConsoleColorOutput {
+	output := &ConsoleColorOutput{
+		writer: color.Output,
+	}
+	output.Name = "console"
+	output.outputThreshold = &std.outputThreshold
+	output.followed = output.outputThreshold
+	return output
+}
+
+// WriteRecord acts as a modifier pre-output for the logs.
 // Here we can add additional information (such the function the log is in)
 // or styling, such as coloration
-func (output Output) ConsoleColorWriter(p []byte) (n int, err error) {
+// the colored line is built first and then written at once while holding consoleMu,
+// so that the ANSI sequences of lines logged by concurrent goroutines never interleave
+func (output *ConsoleColorOutput) WriteRecord(record *Record) error {
+	c := output.color
+	if c == nil {
+		c = record.color
+	}
+	// printDebug adds the time, file, etc. to the message
+	line := string(output.Format.render(record, record.printDebug))
+	if c == nil {
+		c = color.New(color.Reset)
+	}
+	spans := highlightSpans(line, record.Level, append(output.HighlightFilters[:len(output.HighlightFilters):len(output.HighlightFilters)], record.highlights...))
+	// the line is written in the color of the logger, except where highlighted
+	var buf strings.Builder
+	var start int
+	for _, span := range spans {
+		buf.WriteString(c.Sprint(line[start:span.start]))
+		buf.WriteString(span.style.Sprint(line[span.start:span.end]))
+		start = span.end
+	}
+	buf.WriteString(c.Sprint(line[start:]))
+	consoleMu.Lock()
+	defer consoleMu.Unlock()
+	_, err := io.WriteString(output.writer, buf.String())
+	return err
+}
+
+// highlightSpan is a part of a line, from start up to end, to be shown in style
+type highlightSpan struct {
+	start	int
+	end		int
+	style	*color.Color
+}
 
-// NOTE:
-// THIS CURRENTLY ONLY IS DOING STDOUT!!
-// I THINK YOU WANT MORE THAN THAT
-//
-// BEWARE OF COLOR, ON MULTIPLE-THREADS, CAN BE BAD NEWS
-//  when multiple writers are writing to the same output
-//  for example, the console, the output can become corrupt
-//  most likely because of ANSI injection sequences conflicting
-//  but I havent had time to investigate this yet
+// highlightSpans finds the keywords and patterns of the filters applying to level within line,
+// in order and without overlaps, the earliest match, then the first filter, is kept
+func highlightSpans(line string, level Level, filters Filters) []highlightSpan {
+	var spans []highlightSpan
+	for _, filter := range filters {
+		if level < Level(filter.Level) {
+			continue
+		}
+		style := filter.Style
+		if style == nil {
+			style = color.New(color.Bold)
+		}
+		for _, keyword := range filter.Keywords {
+			if keyword == "" {
+				continue
+			}
+			for offset := 0; ; {
+				start, end := indexFold(line, keyword, offset)
+				if start < 0 {
+					break
+				}
+				spans = append(spans, highlightSpan{start, end, style})
+				offset = end
+			}
+		}
+		for _, pattern := range filter.Patterns {
+			for _, match := range pattern.FindAllStringIndex(line, -1) {
+				if match[1] > match[0] {
+					spans = append(spans, highlightSpan{match[0], match[1], style})
+				}
+			}
+		}
+	}
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].start < spans[j].start
+	})
+	var kept []highlightSpan
+	for _, span := range spans {
+		if len(kept) > 0 && span.start < kept[len(kept)-1].end {
+			continue
+		}
+		kept = append(kept, span)
+	}
+	return kept
+}
 
+// Highlight shows the keywords in style wherever they are found in a message of this logger on the console
+func (log *LoggerT) Highlight(style *color.Color, keywords ...string) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	log = log.source()
+	log.HighlightFilters = append(log.HighlightFilters, Filter{Keywords: keywords, Style: style})
+}
 
+// HighlightPattern shows matches of the regular expression pattern in style on the console
+func (log *LoggerT) HighlightPattern(style *color.Color, pattern string) error {
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return err
+	}
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	log = log.source()
+	log.HighlightFilters = append(log.HighlightFilters, Filter{Patterns: []*regexp.Regexp{compiled}, Style: style})
+	return nil
+}
 
-	// split the received message on colons
-	var str string = string(p[:])
-	var strs []string = strings.SplitN(str,":",6)
-	var msg string = str
-	// the first 5 of which are time, etc in a normal message
-	if len(strs) >= 6 {
-			// but the 6th is the message type, ie. DEBUG
-			// which can be used to map back to the logger
-			msg = strs[5]
-	} 
-	// and now we can check if it should be colorized, etc.
-	if !lt.printDebug { 
-			lt.color.Print(msg)
-	} else {
-			lt.color.Print(str)
+// indexFold returns where keyword is first found in s, from offset on, ignoring case as strings.EqualFold does, or -1, -1
+// the offsets are within s itself, as changing the case of s could change its length
+func indexFold(s string, keyword string, offset int) (start int, end int) {
+	for start = offset; start < len(s); {
+		if length := prefixFold(s[start:], keyword); length >= 0 {
+			return start, start + length
+		}
+		_, size := utf8.DecodeRuneInString(s[start:])
+		start += size
 	}
-	return len(p), nil
+	return -1, -1
 }
 
-func (output *Output) SetFilters(filters []Filters){
-	//set filters only for this Output
+// prefixFold returns the length in bytes of keyword at the start of s, ignoring case, or -1 when s does not start with it
+func prefixFold(s string, keyword string) int {
+	length := 0
+	for _, want := range keyword {
+		if length >= len(s) {
+			return -1
+		}
+		r, size := utf8.DecodeRuneInString(s[length:])
+		if !equalFoldRune(r, want) {
+			return -1
+		}
+		length += size
+	}
+	return length
 }
 
-func (log *LoggerT) SetFilters(filters []Filters){
-	//set filters for all Writers
+// equalFoldRune is true when a and b are the same rune under Unicode case folding
+func equalFoldRune(a rune, b rune) bool {
+	if a == b {
+		return true
+	}
+	for r := unicode.SimpleFold(a); r != a; r = unicode.SimpleFold(r) {
+		if r == b {
+			return true
+		}
+	}
+	return false
 }
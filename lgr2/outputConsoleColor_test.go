@@ -0,0 +1,40 @@
+package lgr
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestHighlightSpans(t *testing.T) {
+	tests := []struct {
+		line    string
+		keyword string
+		matches []string
+	}{
+		{"request timeout, retrying", "timeout", []string{"timeout"}},
+		{"TIMEOUT then Timeout", "timeout", []string{"TIMEOUT", "Timeout"}},
+		// Ⱥ is 2 bytes, but its lower case ⱥ is 3
+		{strings.Repeat("Ⱥ", 10) + " timeout", "timeout", []string{"timeout"}},
+		{strings.Repeat("Ⱥ", 10) + " timeout", "ⱥⱥ", []string{"ȺȺ", "ȺȺ", "ȺȺ", "ȺȺ", "ȺȺ"}},
+		// İ is 2 bytes, but its lower case i̇ is 3
+		{"İstanbul İSTANBUL timeout", "timeout", []string{"timeout"}},
+		{"ſtop STOP", "stop", []string{"ſtop", "STOP"}},
+		{"Größe GRÖSSE", "größe", []string{"Größe"}},
+		{"nothing here", "timeout", nil},
+	}
+	for _, test := range tests {
+		spans := highlightSpans(test.line, LevelInfo, Filters{{Keywords: []string{test.keyword}}})
+		var matches []string
+		for _, span := range spans {
+			match := test.line[span.start:span.end]
+			if !utf8.ValidString(match) {
+				t.Errorf("highlightSpans(%q, %q) split a rune, %q", test.line, test.keyword, match)
+			}
+			matches = append(matches, match)
+		}
+		if strings.Join(matches, "|") != strings.Join(test.matches, "|") {
+			t.Errorf("highlightSpans(%q, %q) matched %q, expected %q", test.line, test.keyword, matches, test.matches)
+		}
+	}
+}
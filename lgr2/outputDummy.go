@@ -1,20 +1,19 @@
 package lgr
 
-import "ioutil"
-
 
 type DiscardOutput struct {
 	Output
-	fileHandle		os.File
 }
 
 
-// DiscardLogging Disables logging
-func (output *Output) DiscardLogging() {
-	output.fileHandle = ioutil.Discard
+// NewDiscardOutput returns an Output which writes nothing
+func NewDiscardOutput() *DiscardOutput {
+	output := &DiscardOutput{}
+	output.Name = "discard"
+	return output
 }
 
-//  func (output *FileHandle) 
-func (output *Output) Writer(p []byte) (n int, err error) {
-	
+// WriteRecord discards the record
+func (output *DiscardOutput) WriteRecord(record *Record) error {
+	return nil
 }
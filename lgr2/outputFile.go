@@ -1,42 +1,183 @@
 package lgr
 
 import (
+	"fmt"
+	"io/ioutil"
 	"os"
-	"ioutil"
+	"strings"
+	"sync"
+	"time"
 )
 
 type FileOutput struct {
 	Output
-	fileHandle		os.File
-	filePath		string
+	fileHandle		*os.File
+	filePath		string				// filePath is the log file currently written to
+	basePath		string				// basePath is the path the log file was set to, dated files are named from it
+	size			int64				// size is the current size of the log file in bytes
+	periodEnd		time.Time			// periodEnd is when the next scheduled rotation is due
+	mu				sync.Mutex			// mu guards the file while it is written to and rotated
+	housekeeping	sync.Mutex			// housekeeping guards the backups while they are compressed and removed, taken after mu
+	Format			Format
+	MaxSize			int64				// MaxSize in bytes the log file may reach before it is rotated, 0 never rotates
+	MaxBackups		int					// MaxBackups is the number of rotated files to keep, 0 keeps them all
+	Compress		bool				// Compress rotated files with gzip
+	TimestampBackups	bool			// TimestampBackups names rotated files <path>.<time> rather than <path>.<number>
+	RotateEvery		Period				// RotateEvery starts a new log file, <path>.<date>, each hour or day
+	UTC				bool				// UTC places the RotateEvery boundaries in UTC rather than local time
+	MaxAge			time.Duration		// MaxAge removes log files last written longer ago than MaxAge, 0 keeps them all
+	MaxTotalSize	int64				// MaxTotalSize in bytes of all of the log files, the oldest are removed past it
+	Symlink			string				// Symlink is a path, ie. /var/log/app/current, kept pointing at the log file, set before SetLogFile
 }
 
 
 // UseTempLogFile Creates a temporary file and sets the Log Handle to a io.writer created for it
 // prefix is a string to be used as the filename prefix for the temporary file
-func UseTempLogFile(prefix string) ( success bool, error err ) {
+// the output is returned, to be added to the loggers, unless there is an error
+func (output *FileOutput) UseTempLogFile(prefix string) ( *FileOutput, error ) {
 	file, err := ioutil.TempFile(os.TempDir(), prefix)
 	if err != nil {
-		return false, error.Errorf("Failed to open log file:%s\n%s", path, err)
+		return nil, fmt.Errorf("Failed to open temporary log file:%s\n%s", prefix, err)
 	}
-	output.fileHandle = file
-	output.filePath = file.Name()
-	return true, nil
+	file.Close()
+	return output.SetLogFile(file.Name())
 }
 
 // SetLogFile Sets the Log Handle to an io.writer
 // takes a single string argument of `path` which is the path to be used as the log file
 // This file will be appended to or created
-func (output *FileHandle) SetLogFile(path string) ( success bool, error err ) {
+// when RotateEvery is set, path is the base of the dated file names, <path>.<date>
+// the output is returned, to be added to the loggers, unless there is an error
+//  output, err := (&lgr.FileOutput{MaxSize: 10 << 20}).SetLogFile("/var/log/app.log")
+func (output *FileOutput) SetLogFile(path string) ( *FileOutput, error ) {
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	output.basePath = path
+	var err error
+	if output.RotateEvery != RotateNever {
+		err = output.openPeriod(time.Now())
+	} else if err = output.openFile(path); err == nil {
+		// the path of the log file only changes here, as rotating by size keeps writing to path
+		err = output.linkLogFile()
+	}
+	if err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// SetLogFileFallback uses the first of paths which can be opened as the log file, see SetLogFile
+// ie. output.SetLogFileFallback("/var/log/app.log", filepath.Join(os.TempDir(), "app.log"))
+// when none of them can be, the output writes to stderr instead, and is returned with an error listing why each failed
+func (output *FileOutput) SetLogFileFallback(paths ...string) ( *FileOutput, error ) {
+	var failures []string
+	for _, path := range paths {
+		if _, err := output.SetLogFile(path); err != nil {
+			failures = append(failures, err.Error())
+			continue
+		}
+		return output, nil
+	}
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	if output.fileHandle != nil && output.filePath != "" {
+		output.fileHandle.Close()
+	}
+	output.fileHandle = os.Stderr
+	output.filePath = ""
+	output.basePath = ""
+	return output, fmt.Errorf("Failed to open any log file, writing to stderr:%s\n%s", strings.Join(paths, ", "), strings.Join(failures, "\n"))
+}
+
+// openFile closes the current log file, if any, and opens path in its place
+// the caller must hold output.mu
+func (output *FileOutput) openFile(path string) error {
 	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0666)
 	if err != nil {
-		return false, error.Errorf("Failed to open log file:%s\n%s", path, err)
+		return fmt.Errorf("Failed to open log file:%s\n%s", path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("Failed to open log file:%s\n%s", path, err)
+	}
+	// the fileHandle has no path when it is stderr, see SetLogFileFallback, which is never closed
+	if output.fileHandle != nil && output.filePath != "" {
+		output.fileHandle.Close()
 	}
 	output.fileHandle = file
 	output.filePath = path
-	return true, nil
+	output.size = info.Size()
+	return nil
 }
 
-func (output *FileHandle) Writer(p []byte) (n int, err error) {
-	
+// Close closes the log file, later records are not written until SetLogFile is used again
+func (output *FileOutput) Close() error {
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	file, path := output.fileHandle, output.filePath
+	output.fileHandle = nil
+	output.filePath = ""
+	output.basePath = ""
+	// stderr, see SetLogFileFallback, is never closed
+	if file == nil || path == "" {
+		return nil
+	}
+	return file.Close()
+}
+
+// Flush commits the log file to disk
+func (output *FileOutput) Flush() {
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	if output.fileHandle != nil && output.filePath != "" {
+		output.fileHandle.Sync()
+	}
+}
+
+// WriteRecord writes the record to the log file as a single line in the chosen Format
+// the file is rotated first if it is due by RotateEvery or the line would take it past MaxSize
+// the line is still written when rotating, or compressing and removing old files, fails, that error is returned after
+func (output *FileOutput) WriteRecord(record *Record) error {
+	output.mu.Lock()
+	backup, err := output.writeRecord(record)
+	output.mu.Unlock()
+	if backup != "" {
+		if housekeepErr := output.housekeep(backup); err == nil {
+			err = housekeepErr
+		}
+	}
+	return err
+}
+
+// writeRecord is WriteRecord for a caller holding output.mu, the backup made by rotating, if any, is returned for housekeep
+func (output *FileOutput) writeRecord(record *Record) (backup string, err error) {
+	if output.fileHandle == nil {
+		if output.filePath == "" {
+			return "", nil
+		}
+		// an earlier rotation could not open the log file again
+		if err := output.openFile(output.filePath); err != nil {
+			return "", err
+		}
+	}
+	if output.RotateEvery != RotateNever && output.filePath != "" && !record.Time.Before(output.periodEnd) {
+		err = output.openPeriod(record.Time)
+	}
+	line := output.Format.render(record, true)
+	if output.MaxSize > 0 && output.filePath != "" && output.size > 0 && output.size+int64(len(line)) > output.MaxSize {
+		var rotateErr error
+		if backup, rotateErr = output.rotate(); err == nil {
+			err = rotateErr
+		}
+		if output.fileHandle == nil {
+			return "", err
+		}
+	}
+	n, writeErr := output.fileHandle.Write(line)
+	output.size += int64(n)
+	if writeErr != nil {
+		err = writeErr
+	}
+	return backup, err
 }
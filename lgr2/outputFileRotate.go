@@ -0,0 +1,330 @@
+package lgr
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// backupTimeFormat is the time format used in the name of timestamped backups
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// rotate closes the log file, moves it to a backup and opens a new, empty, log file at the same path
+// the backup is returned for housekeep, which must be called once output.mu is released, output.housekeeping is held until then
+// when the file cannot be moved it is opened again at its path, so that records are still written to it
+// the caller must hold output.mu
+func (output *FileOutput) rotate() (backup string, err error) {
+	closeErr := output.fileHandle.Close()
+	// the old handle is closed, or unusable
+	output.fileHandle = nil
+	if closeErr != nil {
+		err = fmt.Errorf("Failed to close log file for rotation:%s\n%s", output.filePath, closeErr)
+	} else {
+		output.housekeeping.Lock()
+		if backup, err = output.moveToBackup(); err != nil {
+			output.housekeeping.Unlock()
+		}
+	}
+	if openErr := output.openFile(output.filePath); openErr != nil {
+		if backup != "" {
+			output.housekeeping.Unlock()
+		}
+		if err == nil {
+			err = openErr
+		}
+		return "", err
+	}
+	return backup, err
+}
+
+// moveToBackup renames the closed log file to its backup, numbered or timestamped, and returns the backup
+// the caller must hold output.mu and output.housekeeping
+func (output *FileOutput) moveToBackup() (backup string, err error) {
+	if output.TimestampBackups {
+		// never overwrite a backup from earlier in the same millisecond
+		for now := time.Now(); backup == "" || fileExists(backup) || fileExists(backup+".gz"); now = now.Add(time.Millisecond) {
+			backup = output.filePath + "." + now.Format(backupTimeFormat)
+		}
+	} else {
+		if err := output.shiftBackups(); err != nil {
+			return "", err
+		}
+		backup = output.filePath + ".1"
+	}
+	if err := os.Rename(output.filePath, backup); err != nil {
+		return "", fmt.Errorf("Failed to rotate log file:%s\n%s", output.filePath, err)
+	}
+	return backup, nil
+}
+
+// housekeep compresses the backup made by rotate, then prunes the backups to MaxBackups and applies the retention policy
+// it is called without output.mu, so that records are still written while a large file is compressed,
+// but with output.housekeeping, held since rotate, which keeps other rotations from shifting the backup meanwhile
+func (output *FileOutput) housekeep(backup string) (err error) {
+	if output.Compress {
+		err = compressFile(backup)
+	}
+	output.housekeeping.Unlock()
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	output.housekeeping.Lock()
+	defer output.housekeeping.Unlock()
+	if pruneErr := output.pruneBackups(); err == nil {
+		err = pruneErr
+	}
+	if retentionErr := output.applyRetention(); err == nil {
+		err = retentionErr
+	}
+	return err
+}
+
+// Period is how often a FileOutput starts a new log file
+type Period int
+
+const (
+	// RotateNever keeps writing to the same log file
+	RotateNever Period = iota
+	// RotateHourly starts a new log file, <path>.2006-01-02T15, every hour
+	RotateHourly
+	// RotateDaily starts a new log file, <path>.2006-01-02, every day
+	RotateDaily
+)
+
+// start returns the beginning of the period which t is in
+func (period Period) start(t time.Time) time.Time {
+	year, month, day := t.Date()
+	if period == RotateHourly {
+		return time.Date(year, month, day, t.Hour(), 0, 0, 0, t.Location())
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// next returns the beginning of the period following the one starting at start
+func (period Period) next(start time.Time) time.Time {
+	if period == RotateHourly {
+		return start.Add(time.Hour)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// layout is the time format used to name the log file of each period
+func (period Period) layout() string {
+	if period == RotateHourly {
+		return "2006-01-02T15"
+	}
+	return "2006-01-02"
+}
+
+// openPeriod switches to the log file of the period that now is in, <path>.<date>
+// then points the Symlink at it and applies the retention policy
+// the caller must hold output.mu
+func (output *FileOutput) openPeriod(now time.Time) error {
+	if output.UTC {
+		now = now.UTC()
+	} else {
+		now = now.Local()
+	}
+	start := output.RotateEvery.start(now)
+	if err := output.openFile(output.basePath + "." + start.Format(output.RotateEvery.layout())); err != nil {
+		return err
+	}
+	output.periodEnd = output.RotateEvery.next(start)
+	if err := output.linkLogFile(); err != nil {
+		return err
+	}
+	output.housekeeping.Lock()
+	defer output.housekeeping.Unlock()
+	return output.applyRetention()
+}
+
+// linkLogFile points the Symlink, if there is one, at the log file
+// the link is made relative to the directory of the Symlink, so that it resolves whatever the working directory is
+// the caller must hold output.mu
+func (output *FileOutput) linkLogFile() error {
+	if output.Symlink == "" {
+		return nil
+	}
+	target, err := filepath.Abs(output.filePath)
+	if err == nil {
+		var dir string
+		if dir, err = filepath.Abs(filepath.Dir(output.Symlink)); err == nil {
+			target, err = filepath.Rel(dir, target)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("Failed to link log file:%s\n%s", output.Symlink, err)
+	}
+	// replace the link in a single rename, so that it always exists
+	link := output.Symlink + ".new"
+	os.Remove(link)
+	if err := os.Symlink(target, link); err != nil {
+		return fmt.Errorf("Failed to link log file:%s\n%s", output.Symlink, err)
+	}
+	if err := os.Rename(link, output.Symlink); err != nil {
+		return fmt.Errorf("Failed to link log file:%s\n%s", output.Symlink, err)
+	}
+	return nil
+}
+
+// applyRetention removes the log files of this output, <path> and <path>.*, which are older than MaxAge
+// then the oldest until they total no more than MaxTotalSize
+// the file currently written to is never removed
+// the caller must hold output.mu and output.housekeeping
+func (output *FileOutput) applyRetention() error {
+	if output.MaxAge <= 0 && output.MaxTotalSize <= 0 {
+		return nil
+	}
+	matches, err := filepath.Glob(output.basePath + ".*")
+	if err != nil {
+		return err
+	}
+	matches = append(matches, output.basePath)
+	var files []os.FileInfo
+	var paths = map[os.FileInfo]string{}
+	total := output.size
+	for _, match := range matches {
+		if match == output.filePath || match == output.Symlink || match == output.Symlink+".new" {
+			continue
+		}
+		info, err := os.Lstat(match)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, info)
+		paths[info] = match
+		total += info.Size()
+	}
+	// oldest first
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].ModTime().Before(files[j].ModTime())
+	})
+	for _, info := range files {
+		expired := output.MaxAge > 0 && time.Since(info.ModTime()) > output.MaxAge
+		oversize := output.MaxTotalSize > 0 && total > output.MaxTotalSize
+		if !expired && !oversize {
+			continue
+		}
+		if err := os.Remove(paths[info]); err != nil {
+			return fmt.Errorf("Failed to remove old log file:%s\n%s", paths[info], err)
+		}
+		total -= info.Size()
+	}
+	return nil
+}
+
+// backups returns the rotated files of this output, newest first
+func (output *FileOutput) backups() ([]string, error) {
+	matches, err := filepath.Glob(output.filePath + ".*")
+	if err != nil {
+		return nil, err
+	}
+	var backups []string
+	for _, match := range matches {
+		if output.TimestampBackups && output.isTimestampBackup(match) || !output.TimestampBackups && output.backupNumber(match) > 0 {
+			backups = append(backups, match)
+		}
+	}
+	if output.TimestampBackups {
+		// timestamps sort by name, newest last
+		sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	} else {
+		sort.Slice(backups, func(i, j int) bool {
+			return output.backupNumber(backups[i]) < output.backupNumber(backups[j])
+		})
+	}
+	return backups, nil
+}
+
+// backupNumber returns the number of a numbered backup, <path>.<number>[.gz], or 0 when name is not one
+func (output *FileOutput) backupNumber(name string) int {
+	suffix := strings.TrimSuffix(strings.TrimPrefix(name, output.filePath+"."), ".gz")
+	number, err := strconv.Atoi(suffix)
+	if err != nil || number < 1 {
+		return 0
+	}
+	return number
+}
+
+// isTimestampBackup is true when name is a timestamped backup, <path>.<time>[.gz]
+func (output *FileOutput) isTimestampBackup(name string) bool {
+	suffix := strings.TrimSuffix(strings.TrimPrefix(name, output.filePath+"."), ".gz")
+	_, err := time.Parse(backupTimeFormat, suffix)
+	return err == nil
+}
+
+// shiftBackups renames each numbered backup to the next number up, oldest first,
+// so that <path>.1 is free for the file being rotated
+// the caller must hold output.mu and output.housekeeping
+func (output *FileOutput) shiftBackups() error {
+	backups, err := output.backups()
+	if err != nil {
+		return err
+	}
+	for i := len(backups) - 1; i >= 0; i-- {
+		number := output.backupNumber(backups[i])
+		shifted := output.filePath + "." + strconv.Itoa(number+1)
+		if strings.HasSuffix(backups[i], ".gz") {
+			shifted += ".gz"
+		}
+		if err := os.Rename(backups[i], shifted); err != nil {
+			return fmt.Errorf("Failed to rotate log file:%s\n%s", backups[i], err)
+		}
+	}
+	return nil
+}
+
+// pruneBackups removes the oldest backups until no more than MaxBackups remain
+// the caller must hold output.mu and output.housekeeping
+func (output *FileOutput) pruneBackups() error {
+	if output.MaxBackups <= 0 {
+		return nil
+	}
+	backups, err := output.backups()
+	if err != nil {
+		return err
+	}
+	for i := output.MaxBackups; i < len(backups); i++ {
+		if err := os.Remove(backups[i]); err != nil {
+			return fmt.Errorf("Failed to remove old log file:%s\n%s", backups[i], err)
+		}
+	}
+	return nil
+}
+
+// fileExists is true when there is a file at path
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// compressFile gzips path to path.gz and removes path
+func compressFile(path string) (err error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("Failed to compress log file:%s\n%s", path, err)
+	}
+	defer in.Close()
+	out, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		return fmt.Errorf("Failed to compress log file:%s\n%s", path, err)
+	}
+	writer := gzip.NewWriter(out)
+	if _, err = io.Copy(writer, in); err == nil {
+		err = writer.Close()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path + ".gz")
+		return fmt.Errorf("Failed to compress log file:%s\n%s", path, err)
+	}
+	in.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,268 @@
+package lgr
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeLines writes count records of message to output at t, failing the test on any error
+func writeLines(t *testing.T, output *FileOutput, count int, message string, at time.Time) {
+	t.Helper()
+	for i := 0; i < count; i++ {
+		if err := output.WriteRecord(&Record{Time: at, Level: LevelInfo, Name: "INFO", Message: message}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// logFiles returns the names of the files in dir, sorted
+func logFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+// readLog returns the content of path, which must exist
+func readLog(t *testing.T, path string) string {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func TestFileOutputMaxSize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	output, err := (&FileOutput{MaxSize: 200}).SetLogFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer output.Close()
+	writeLines(t, output, 10, "a line of about forty bytes", time.Now())
+
+	backups := 0
+	for _, name := range logFiles(t, dir) {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() > 200 {
+			t.Errorf("%s is %d bytes, past MaxSize", name, info.Size())
+		}
+		if name != "app.log" {
+			backups++
+		}
+	}
+	if backups == 0 {
+		t.Errorf("no backups in %v", logFiles(t, dir))
+	}
+	if got := readLog(t, path + ".1"); !strings.Contains(got, "a line of about forty bytes") {
+		t.Errorf("backup holds %q", got)
+	}
+}
+
+func TestFileOutputMaxBackups(t *testing.T) {
+	for _, timestamped := range []bool{false, true} {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+		output, err := (&FileOutput{MaxSize: 1, MaxBackups: 2, TimestampBackups: timestamped}).SetLogFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// each line after the first rotates
+		writeLines(t, output, 6, "line", time.Now())
+		output.Close()
+		files := logFiles(t, dir)
+		if len(files) != 3 {
+			t.Errorf("timestamped %v: expected the log file and 2 backups, found %v", timestamped, files)
+		}
+		if !timestamped && (files[1] != "app.log.1" || files[2] != "app.log.2") {
+			t.Errorf("expected backups app.log.1 and app.log.2, found %v", files)
+		}
+	}
+}
+
+func TestFileOutputCompress(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	output, err := (&FileOutput{MaxSize: 1, Compress: true}).SetLogFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer output.Close()
+	writeLines(t, output, 1, "first", time.Now())
+	writeLines(t, output, 1, "second", time.Now())
+
+	if fileExists(path + ".1") {
+		t.Errorf("the uncompressed backup remains, %v", logFiles(t, dir))
+	}
+	file, err := os.Open(path + ".1.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "first") || strings.Contains(string(content), "second") {
+		t.Errorf("compressed backup holds %q", content)
+	}
+	if got := readLog(t, path); !strings.Contains(got, "second") {
+		t.Errorf("log file holds %q", got)
+	}
+}
+
+func TestFileOutputRotateFails(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	output, err := (&FileOutput{MaxSize: 1}).SetLogFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer output.Close()
+	writeLines(t, output, 1, "first", time.Now())
+	// the file cannot be renamed once it is gone
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	err = output.WriteRecord(&Record{Time: time.Now(), Level: LevelInfo, Name: "INFO", Message: "second"})
+	if err == nil || !strings.Contains(err.Error(), "Failed to rotate log file") {
+		t.Errorf("expected the rotation to fail, got %v", err)
+	}
+	// the line is written to the log file opened again at its path, which rotates as before
+	writeLines(t, output, 1, "third", time.Now())
+	if got := readLog(t, path + ".1"); got != "second\n" {
+		t.Errorf("backup holds %q", got)
+	}
+	if got := readLog(t, path); got != "third\n" {
+		t.Errorf("log file holds %q", got)
+	}
+}
+
+func TestFileOutputRotateEvery(t *testing.T) {
+	tests := []struct {
+		period Period
+		later  time.Duration
+		name   string
+	}{
+		{RotateHourly, time.Hour, "app.log.2024-03-05T08"},
+		{RotateDaily, 24 * time.Hour, "app.log.2024-03-06"},
+	}
+	start := time.Date(2024, 3, 5, 7, 30, 0, 0, time.UTC)
+	for _, test := range tests {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.log")
+		output := &FileOutput{RotateEvery: test.period, UTC: true, Symlink: filepath.Join(dir, "current")}
+		output.mu.Lock()
+		output.basePath = path
+		err := output.openPeriod(start)
+		output.mu.Unlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeLines(t, output, 1, "first", start)
+		writeLines(t, output, 1, "second", start.Add(test.later))
+		output.Close()
+
+		first := filepath.Join(dir, "app.log."+start.Format(test.period.layout()))
+		if got := readLog(t, first); !strings.Contains(got, "first") || strings.Contains(got, "second") {
+			t.Errorf("%s holds %q", first, got)
+		}
+		if got := readLog(t, filepath.Join(dir, test.name)); !strings.Contains(got, "second") {
+			t.Errorf("%s holds %q", test.name, got)
+		}
+		if target, err := os.Readlink(output.Symlink); err != nil || target != test.name {
+			t.Errorf("link points at %q, %v, expected %s", target, err, test.name)
+		}
+	}
+}
+
+func TestFileOutputSymlink(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "logs"), 0777); err != nil {
+		t.Fatal(err)
+	}
+	// relative paths, the link must resolve from its own directory rather than the working directory
+	t.Chdir(dir)
+	output, err := (&FileOutput{MaxSize: 1, Symlink: "current"}).SetLogFile(filepath.Join("logs", "app.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer output.Close()
+	writeLines(t, output, 2, "line", time.Now())
+	target, err := os.Readlink("current")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target != filepath.Join("logs", "app.log") {
+		t.Errorf("link points at %q", target)
+	}
+	if got := readLog(t, "current"); !strings.Contains(got, "line") {
+		t.Errorf("linked log file holds %q", got)
+	}
+}
+
+func TestFileOutputRetention(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	old := time.Now().Add(-48 * time.Hour)
+	for i, name := range []string{"app.log.old", "app.log.older", "app.log.recent"} {
+		stale := filepath.Join(dir, name)
+		if err := os.WriteFile(stale, make([]byte, 1000), 0666); err != nil {
+			t.Fatal(err)
+		}
+		// app.log.recent is the newest, but still older than the log file
+		modified := old.Add(time.Duration(-i) * time.Hour)
+		if name == "app.log.recent" {
+			modified = time.Now().Add(-time.Minute)
+		}
+		if err := os.Chtimes(stale, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// MaxAge removes the two files older than a day, once a rotation applies the retention policy
+	output, err := (&FileOutput{MaxSize: 1, MaxAge: 24 * time.Hour}).SetLogFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeLines(t, output, 2, "line", time.Now())
+	output.Close()
+	files := logFiles(t, dir)
+	if strings.Join(files, " ") != "app.log app.log.1 app.log.recent" {
+		t.Errorf("after MaxAge found %v", files)
+	}
+
+	// MaxTotalSize removes the oldest, app.log.recent, to fit the rest
+	output, err = (&FileOutput{MaxSize: 1, MaxTotalSize: 1000}).SetLogFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeLines(t, output, 1, "line", time.Now())
+	output.Close()
+	files = logFiles(t, dir)
+	if strings.Join(files, " ") != "app.log app.log.1 app.log.2" {
+		t.Errorf("after MaxTotalSize found %v", files)
+	}
+}
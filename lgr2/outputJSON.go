@@ -0,0 +1,32 @@
+package lgr
+
+import "io"
+import "sync"
+
+
+// JSONOutput writes one JSON object per line for each Record
+// {"time":..,"level":"ERROR","level_num":5,"msg":..,"file":..,"line":..,"func":..,"prefix":[..],"fields":{..}}
+type JSONOutput struct {
+	Output
+	writer		io.Writer
+	mu			sync.Mutex				// mu serializes the writes, writer need not be safe for concurrent use
+}
+
+// NewJSONOutput returns an Output writing JSON lines to writer
+// it follows the global log threshold, see SetLogThreshold
+func NewJSONOutput(writer io.Writer) *JSONOutput {
+	output := &JSONOutput{
+		writer: writer,
+	}
+	output.Name = "json"
+	return output
+}
+
+// WriteRecord writes the record as a single line of JSON
+func (output *JSONOutput) WriteRecord(record *Record) error {
+	line := formatJSON(record)
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	_, err := output.writer.Write(line)
+	return err
+}
@@ -0,0 +1,198 @@
+//go:build linux
+// +build linux
+
+package lgr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// DefaultJournalSocket is where systemd-journald listens for the native protocol
+const DefaultJournalSocket = "/run/systemd/journal/socket"
+
+func init() {
+	configOutputs["journal"] = func(config OutputConfig) (OutputI, error) {
+		output, err := NewJournalOutput(config.SocketPath)
+		if err != nil {
+			return nil, err
+		}
+		if config.AppName != "" {
+			output.Identifier = config.AppName
+		}
+		return output, nil
+	}
+}
+
+// JournalOutput sends each Record to systemd-journald using its native protocol
+// the caller is sent as CODE_FILE, CODE_LINE and CODE_FUNC, and the fields of the LoggerT as journal fields,
+// those named as a field journald or this output gives a meaning, ie. MESSAGE or PRIORITY, are sent as LGR_<name>
+// https://systemd.io/JOURNAL_NATIVE_PROTOCOL/
+type JournalOutput struct {
+	Output
+	SocketPath	string
+	Identifier	string				// Identifier is sent as SYSLOG_IDENTIFIER, it defaults to the name of the program
+	conn		*net.UnixConn
+}
+
+// NewJournalOutput connects to journald at socketPath, when empty DefaultJournalSocket is used
+func NewJournalOutput(socketPath string) (*JournalOutput, error) {
+	output := &JournalOutput{
+		SocketPath: socketPath,
+		Identifier: filepath.Base(os.Args[0]),
+	}
+	output.Name = "journal"
+	if output.SocketPath == "" {
+		output.SocketPath = DefaultJournalSocket
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: output.SocketPath, Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect to journald:%s\n%s", output.SocketPath, err)
+	}
+	output.conn = conn
+	return output, nil
+}
+
+// WriteRecord sends the record as a single journal entry
+// entries too large for a datagram are passed to journald in a file descriptor
+func (output *JournalOutput) WriteRecord(record *Record) error {
+	var message bytes.Buffer
+	for _, prefix := range record.Prefix {
+		fmt.Fprint(&message, prefix, " ")
+	}
+	message.WriteString(record.Message)
+
+	var entry bytes.Buffer
+	writeJournalField(&entry, "MESSAGE", message.String())
+	writeJournalField(&entry, "PRIORITY", strconv.Itoa(syslogSeverity(record.Level)))
+	writeJournalField(&entry, "SYSLOG_IDENTIFIER", output.Identifier)
+	writeJournalField(&entry, "LGR_LEVEL", record.Name)
+	if record.File != "" {
+		writeJournalField(&entry, "CODE_FILE", record.File)
+		writeJournalField(&entry, "CODE_LINE", strconv.Itoa(record.Line))
+	}
+	if record.Function != "" {
+		writeJournalField(&entry, "CODE_FUNC", record.Function)
+	}
+	for _, field := range record.Fields {
+		writeJournalField(&entry, journalFieldName(field.Key), fmt.Sprint(field.Value))
+	}
+	if len(record.Stack) > 0 {
+		writeJournalField(&entry, "LGR_STACK", stackText(record.Stack, "\n"))
+	}
+
+	_, err := output.conn.Write(entry.Bytes())
+	if errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS) {
+		return output.writeLarge(entry.Bytes())
+	}
+	return err
+}
+
+// writeLarge passes an entry to journald as an unlinked file, which it reads in place of the datagram
+func (output *JournalOutput) writeLarge(entry []byte) error {
+	file, err := os.CreateTemp("/dev/shm", "lgr-journal-")
+	if err != nil {
+		if file, err = os.CreateTemp("", "lgr-journal-"); err != nil {
+			return err
+		}
+	}
+	defer file.Close()
+	os.Remove(file.Name())
+	if _, err := file.Write(entry); err != nil {
+		return err
+	}
+	// the connection is already connected, which WriteMsgUnix refuses, so sendmsg is used directly
+	rawConn, err := output.conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	controlErr := rawConn.Write(func(fd uintptr) bool {
+		err = syscall.Sendmsg(int(fd), nil, syscall.UnixRights(int(file.Fd())), nil, 0)
+		return err != syscall.EAGAIN
+	})
+	if controlErr != nil {
+		return controlErr
+	}
+	return err
+}
+
+// Close closes the connection to journald
+func (output *JournalOutput) Close() error {
+	return output.conn.Close()
+}
+
+// writeJournalField writes KEY=value, or for values holding a newline,
+// KEY, a newline, the length as a little-endian uint64, then the value
+func writeJournalField(buf *bytes.Buffer, key string, value string) {
+	buf.WriteString(key)
+	if !strings.Contains(value, "\n") {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journalReservedFields are the fields that journald, or JournalOutput itself, gives a meaning,
+// a field of the LoggerT must not pass for one of them
+var journalReservedFields = map[string]bool{
+	"MESSAGE":            true,
+	"MESSAGE_ID":         true,
+	"PRIORITY":           true,
+	"CODE_FILE":          true,
+	"CODE_LINE":          true,
+	"CODE_FUNC":          true,
+	"ERRNO":              true,
+	"INVOCATION_ID":      true,
+	"USER_INVOCATION_ID": true,
+	"SYSLOG_FACILITY":    true,
+	"SYSLOG_IDENTIFIER":  true,
+	"SYSLOG_PID":         true,
+	"SYSLOG_TIMESTAMP":   true,
+	"SYSLOG_RAW":         true,
+	"DOCUMENTATION":      true,
+	"TID":                true,
+	"UNIT":               true,
+	"USER_UNIT":          true,
+	"LGR_LEVEL":          true,
+	"LGR_STACK":          true,
+}
+
+// journalFieldName makes key a valid journal field name,
+// upper case letters, digits and underscores, not starting with an underscore or digit and at most 64 long
+// a reserved name, see journalReservedFields, is given the prefix LGR_
+func journalFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+			case r >= 'a' && r <= 'z':
+				return r - 'a' + 'A'
+			case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+				return r
+			default:
+				return '_'
+		}
+	}, key)
+	name = strings.TrimLeft(name, "_0123456789")
+	if name == "" {
+		name = "FIELD"
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	if journalReservedFields[name] {
+		name = "LGR_" + name
+	}
+	return name
+}
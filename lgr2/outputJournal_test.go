@@ -0,0 +1,128 @@
+//go:build linux
+// +build linux
+
+package lgr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// listenJournal listens on a unixgram socket in a temporary directory, standing in for journald
+func listenJournal(t *testing.T) (*net.UnixConn, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "socket")
+	listener, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	listener.SetReadBuffer(1 << 20)
+	return listener, path
+}
+
+func TestJournalOutput(t *testing.T) {
+	listener, path := listenJournal(t)
+	output, err := NewJournalOutput(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer output.Close()
+	output.Identifier = "app"
+	record := &Record{
+		Level:    LevelError,
+		Name:     "ERROR",
+		Message:  "disk full",
+		Prefix:   PrefixList{"db"},
+		Fields:   Fields{{Key: "request-id", Value: 7}, {Key: "message", Value: "spoof"}, {Key: "lgr_level", Value: "spoof"}},
+		File:     "/src/main.go",
+		Line:     12,
+		Function: "main.main",
+		Stack:    []StackFrame{{Function: "main.main", File: "/src/main.go", Line: 12}, {Function: "main.run", File: "/src/run.go", Line: 3}},
+	}
+	if err := output.WriteRecord(record); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4096)
+	listener.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := listener.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := buf[:n]
+	for _, want := range []string{
+		"MESSAGE=db disk full\n",
+		"PRIORITY=3\n",
+		"SYSLOG_IDENTIFIER=app\n",
+		"LGR_LEVEL=ERROR\n",
+		"CODE_FILE=/src/main.go\n",
+		"CODE_LINE=12\n",
+		"CODE_FUNC=main.main\n",
+		"REQUEST_ID=7\n",
+		"LGR_MESSAGE=spoof\n",
+		"LGR_LGR_LEVEL=spoof\n",
+	} {
+		if !bytes.Contains(entry, []byte(want)) {
+			t.Errorf("entry %q is missing %q", entry, want)
+		}
+	}
+	// the fields of the LoggerT never replace those of the entry
+	if bytes.Contains(entry, []byte("\nMESSAGE=spoof")) || bytes.Contains(entry, []byte("\nLGR_LEVEL=spoof")) {
+		t.Errorf("entry %q holds a field named as a reserved one", entry)
+	}
+	// a value with a newline is sent with its length, rather than after =
+	stack := "main.main main.go:12\nmain.run run.go:3"
+	var want bytes.Buffer
+	want.WriteString("LGR_STACK\n")
+	binary.Write(&want, binary.LittleEndian, uint64(len(stack)))
+	want.WriteString(stack + "\n")
+	if !bytes.Contains(entry, want.Bytes()) {
+		t.Errorf("entry %q is missing the stack %q", entry, want.Bytes())
+	}
+}
+
+func TestJournalOutputLarge(t *testing.T) {
+	listener, path := listenJournal(t)
+	output, err := NewJournalOutput(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer output.Close()
+	// larger than a datagram may be, so it is passed in a file descriptor
+	message := strings.Repeat("x", 4<<20)
+	if err := output.WriteRecord(&Record{Level: LevelInfo, Name: "INFO", Message: message}); err != nil {
+		t.Fatal(err)
+	}
+	oob := make([]byte, syscall.CmsgSpace(4))
+	listener.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, oobn, _, _, err := listener.ReadMsgUnix(make([]byte, 1), oob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(messages) != 1 {
+		t.Fatalf("expected a single control message, got %d, %v", len(messages), err)
+	}
+	fds, err := syscall.ParseUnixRights(&messages[0])
+	if err != nil || len(fds) != 1 {
+		t.Fatalf("expected a single file descriptor, got %v, %v", fds, err)
+	}
+	file := os.NewFile(uintptr(fds[0]), "entry")
+	defer file.Close()
+	// the descriptor shares the offset of the writer, which is at the end, journald reads from the start
+	entry, err := io.ReadAll(io.NewSectionReader(file, 0, 8<<20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(entry, []byte("MESSAGE="+message+"\n")) {
+		t.Errorf("entry of %d bytes is missing the message", len(entry))
+	}
+}
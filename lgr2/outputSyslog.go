@@ -0,0 +1,242 @@
+package lgr
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// syslog facilities, see RFC 5424 6.2.1
+const (
+	FacilityKern	= 0
+	FacilityUser	= 1
+	FacilityDaemon	= 3
+	FacilityAuth	= 4
+	FacilitySyslog	= 5
+	FacilityLocal0	= 16
+	FacilityLocal1	= 17
+	FacilityLocal2	= 18
+	FacilityLocal3	= 19
+	FacilityLocal4	= 20
+	FacilityLocal5	= 21
+	FacilityLocal6	= 22
+	FacilityLocal7	= 23
+)
+
+// syslogSeverity maps each level onto a syslog severity, see RFC 5424 6.2.1
+//  TRACE, DEBUG -> 7 Debug
+//  INFO         -> 6 Informational
+//  MSG          -> 5 Notice
+//  WARN         -> 4 Warning
+//  ERROR        -> 3 Error
+//  CRITICAL     -> 2 Critical
+//  FATAL        -> 1 Alert
+func syslogSeverity(level Level) int {
+	switch levelCheck(level) {
+		case LevelTrace, LevelDebug:
+			return 7
+		case LevelInfo:
+			return 6
+		case LevelMsg:
+			return 5
+		case LevelWarn:
+			return 4
+		case LevelError:
+			return 3
+		case LevelCritical:
+			return 2
+		default:
+			return 1
+	}
+}
+
+// syslogSDID is the SD-ID structured fields are sent under in RFC 5424 messages
+const syslogSDID = "lgr@32473"
+
+// SyslogDialTimeout is how long connecting to the syslog server may take
+var SyslogDialTimeout = 5 * time.Second
+
+// SyslogMaxBackoff is the longest a SyslogOutput waits before trying to reconnect again,
+// the wait starts at a second and doubles with each failure, records written meanwhile are not sent
+var SyslogMaxBackoff = time.Minute
+
+// SyslogOutput sends each Record to a syslog server, such as rsyslog
+//  udp            one message per datagram
+//  tcp            octet-counted framing, RFC 6587
+//  unix           the local socket, ie. /dev/log, as datagrams or else a stream
+//  unixgram       the local socket as datagrams
+type SyslogOutput struct {
+	Output
+	Network		string
+	Address		string
+	Facility	int
+	AppName		string				// AppName defaults to the name of the program
+	Hostname	string				// Hostname defaults to os.Hostname
+	RFC3164		bool				// RFC3164 sends the older BSD format rather than RFC 5424
+	conn		net.Conn
+	stream		bool				// stream is true when conn needs octet-counted framing
+	backoff		time.Duration		// backoff is the wait after the last failed connect, 0 once connected
+	retryAt		time.Time			// retryAt is when connecting may be tried again
+	mu			sync.Mutex
+}
+
+// NewSyslogOutput connects to the syslog server at address over network (udp, tcp, unix or unixgram)
+func NewSyslogOutput(network string, address string, facility int, appName string) (*SyslogOutput, error) {
+	output := &SyslogOutput{
+		Network:  network,
+		Address:  address,
+		Facility: facility,
+		AppName:  appName,
+	}
+	output.Name = "syslog"
+	if output.AppName == "" {
+		output.AppName = filepath.Base(os.Args[0])
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		output.Hostname = hostname
+	}
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	if err := output.connect(); err != nil {
+		return nil, err
+	}
+	return output, nil
+}
+
+// connect dials the syslog server, giving up after SyslogDialTimeout, the caller must hold output.mu
+func (output *SyslogOutput) connect() (err error) {
+	if output.conn != nil {
+		output.conn.Close()
+		output.conn = nil
+	}
+	network := output.Network
+	if network == "unix" {
+		// the local socket is usually datagram, but may be a stream
+		if output.conn, err = net.DialTimeout("unixgram", output.Address, SyslogDialTimeout); err == nil {
+			output.stream = false
+			return nil
+		}
+	}
+	if output.conn, err = net.DialTimeout(network, output.Address, SyslogDialTimeout); err != nil {
+		return fmt.Errorf("Failed to connect to syslog:%s %s\n%s", output.Network, output.Address, err)
+	}
+	output.stream = network == "tcp" || network == "tcp4" || network == "tcp6" || network == "unix"
+	return nil
+}
+
+// WriteRecord sends the record as a single syslog message, reconnecting once if the send fails
+// after a failed reconnect the record is dropped, with an error, until the backoff has passed, see SyslogMaxBackoff
+func (output *SyslogOutput) WriteRecord(record *Record) error {
+	message := output.format(record)
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	if output.conn != nil {
+		if _, err := output.conn.Write(output.frame(message)); err == nil {
+			return nil
+		}
+	}
+	if now := time.Now(); now.Before(output.retryAt) {
+		return fmt.Errorf("Failed to connect to syslog:%s %s\nnext attempt in %s", output.Network, output.Address, output.retryAt.Sub(now).Round(time.Millisecond))
+	}
+	if err := output.connect(); err != nil {
+		output.backoff *= 2
+		if output.backoff < time.Second {
+			output.backoff = time.Second
+		}
+		if output.backoff > SyslogMaxBackoff {
+			output.backoff = SyslogMaxBackoff
+		}
+		output.retryAt = time.Now().Add(output.backoff)
+		return err
+	}
+	output.backoff = 0
+	_, err := output.conn.Write(output.frame(message))
+	return err
+}
+
+// frame adds the octet count to message when the connection is a stream, the caller must hold output.mu
+func (output *SyslogOutput) frame(message []byte) []byte {
+	if !output.stream {
+		return message
+	}
+	return append([]byte(strconv.Itoa(len(message))+" "), message...)
+}
+
+// Close closes the connection to the syslog server
+func (output *SyslogOutput) Close() error {
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	if output.conn == nil {
+		return nil
+	}
+	err := output.conn.Close()
+	output.conn = nil
+	return err
+}
+
+// format renders the record in the RFC 5424, or RFC 3164, syslog format
+func (output *SyslogOutput) format(record *Record) []byte {
+	var buf bytes.Buffer
+	priority := output.Facility*8 + syslogSeverity(record.Level)
+	hostname := syslogToken(output.Hostname, 255)
+	appName := syslogToken(output.AppName, 48)
+	if output.RFC3164 {
+		// <PRI>Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG
+		// the stack is kept on the one line, rather than indented below it
+		fmt.Fprintf(&buf, "<%d>%s %s %s[%d]: ", priority, record.Time.Format(time.Stamp), hostname, appName, os.Getpid())
+		line := *record
+		line.Stack = nil
+		buf.Write(bytes.TrimSuffix(formatText(&line, false), []byte("\n")))
+		if len(record.Stack) > 0 {
+			buf.WriteString(" stack=" + stackText(record.Stack, "; "))
+		}
+		return buf.Bytes()
+	}
+	// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD-ID key="value"] MSG
+	fmt.Fprintf(&buf, "<%d>1 %s %s %s %d %s ", priority, record.Time.Format("2006-01-02T15:04:05.000000Z07:00"), hostname, appName, os.Getpid(), syslogToken(record.Name, 32))
+	if len(record.Fields) == 0 && len(record.Stack) == 0 {
+		buf.WriteString("-")
+	} else {
+		buf.WriteString("[" + syslogSDID)
+		for _, field := range record.Fields {
+			fmt.Fprintf(&buf, " %s=\"%s\"", syslogToken(field.Key, 32), syslogParamEscaper.Replace(fmt.Sprint(field.Value)))
+		}
+		if len(record.Stack) > 0 {
+			fmt.Fprintf(&buf, " stack=\"%s\"", syslogParamEscaper.Replace(stackText(record.Stack, "; ")))
+		}
+		buf.WriteString("]")
+	}
+	buf.WriteString(" ")
+	for _, prefix := range record.Prefix {
+		fmt.Fprint(&buf, prefix, " ")
+	}
+	buf.WriteString(record.Message)
+	return buf.Bytes()
+}
+
+// syslogParamEscaper escapes the characters RFC 5424 requires inside PARAM-VALUE
+var syslogParamEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// syslogToken makes value safe for a header field or SD-NAME, which are printable US-ASCII without spaces,
+// an empty value is the NILVALUE, -
+func syslogToken(value string, max int) string {
+	value = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, value)
+	if len(value) > max {
+		value = value[:max]
+	}
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,186 @@
+package lgr
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// syslogRecord is the record sent to the listeners of these tests
+func syslogRecord() *Record {
+	return &Record{
+		Time:    time.Date(2024, 3, 5, 7, 8, 9, 0, time.UTC),
+		Level:   LevelError,
+		Name:    "ERROR",
+		Message: "disk full",
+		Fields:  Fields{{Key: "path", Value: `/var/"log"`}},
+	}
+}
+
+// checkSyslogMessage checks message is the RFC 5424 rendering of syslogRecord from app at FacilityLocal0
+func checkSyslogMessage(t *testing.T, message string) {
+	t.Helper()
+	// local0 is 16, error is 3
+	want := "<131>1 2024-03-05T07:08:09.000000Z "
+	if !strings.HasPrefix(message, want) {
+		t.Errorf("message %q does not start with %q", message, want)
+	}
+	want = " app " + strconv.Itoa(os.Getpid()) + ` ERROR [lgr@32473 path="/var/\"log\""] disk full`
+	if !strings.HasSuffix(message, want) {
+		t.Errorf("message %q does not end with %q", message, want)
+	}
+}
+
+func TestSyslogOutputUDP(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	output, err := NewSyslogOutput("udp", listener.LocalAddr().String(), FacilityLocal0, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer output.Close()
+	if err := output.WriteRecord(syslogRecord()); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4096)
+	listener.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := listener.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSyslogMessage(t, string(buf[:n]))
+}
+
+func TestSyslogOutputTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	messages := make(chan string, 2)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		// octet-counted framing, <length> <message>
+		reader := bufio.NewReader(conn)
+		for {
+			length, err := reader.ReadString(' ')
+			if err != nil {
+				close(messages)
+				return
+			}
+			size, err := strconv.Atoi(strings.TrimSuffix(length, " "))
+			if err != nil {
+				t.Errorf("bad octet count %q", length)
+				close(messages)
+				return
+			}
+			message := make([]byte, size)
+			if _, err := io.ReadFull(reader, message); err != nil {
+				t.Error(err)
+				close(messages)
+				return
+			}
+			messages <- string(message)
+		}
+	}()
+	output, err := NewSyslogOutput("tcp", listener.Addr().String(), FacilityLocal0, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer output.Close()
+	for i := 0; i < 2; i++ {
+		if err := output.WriteRecord(syslogRecord()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		select {
+			case message, ok := <-messages:
+				if !ok {
+					t.Fatal("connection closed early")
+				}
+				checkSyslogMessage(t, message)
+			case <-time.After(5 * time.Second):
+				t.Fatal("timed out waiting for message")
+		}
+	}
+}
+
+func TestSyslogOutputUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	listener, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	// unix tries datagrams first, as /dev/log usually is
+	output, err := NewSyslogOutput("unix", path, FacilityLocal0, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer output.Close()
+	output.RFC3164 = true
+	output.Hostname = "host"
+	if err := output.WriteRecord(syslogRecord()); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 4096)
+	listener.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := listener.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "<131>Mar  5 07:08:09 host app[" + strconv.Itoa(os.Getpid()) + "]: "
+	if message := string(buf[:n]); !strings.HasPrefix(message, want) || !strings.Contains(message, "disk full") {
+		t.Errorf("message %q does not start with %q and hold the message", message, want)
+	}
+}
+
+func TestSyslogOutputBackoff(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := NewSyslogOutput("tcp", listener.Addr().String(), FacilityLocal0, "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer output.Close()
+	listener.Close()
+	output.mu.Lock()
+	output.conn.Close()
+	output.mu.Unlock()
+
+	// the write on the closed connection fails, then so does reconnecting
+	if err := output.WriteRecord(syslogRecord()); err == nil {
+		t.Fatal("expected an error with the server down")
+	}
+	output.mu.Lock()
+	backoff, retryAt := output.backoff, output.retryAt
+	output.mu.Unlock()
+	if backoff != time.Second || !retryAt.After(time.Now()) {
+		t.Fatalf("backoff %s until %s, expected a second from now", backoff, retryAt)
+	}
+	// within the backoff the record is dropped without dialling
+	start := time.Now()
+	err = output.WriteRecord(syslogRecord())
+	if err == nil || !strings.Contains(err.Error(), "next attempt in") {
+		t.Fatalf("expected the backoff error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("write within the backoff took %s", elapsed)
+	}
+}
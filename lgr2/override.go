@@ -0,0 +1,155 @@
+package lgr
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+)
+
+// levelOverride is a single pattern=LEVEL rule of SetLevelOverrides
+type levelOverride struct {
+	pattern	string
+	level	Level
+}
+
+// overrides holds the rules of SetLevelOverrides,
+// cache remembers the level, or nil, each caller resolved to
+// generation counts the changes of rules, a level resolved from earlier rules is not cached
+var overrides struct {
+	sync.RWMutex
+	rules		[]levelOverride
+	cache		map[string]*Level
+	generation	uint64
+}
+
+// SetLevelOverrides sets the threshold for log calls from matching packages or files, in place of the thresholds of the Outputs
+// rules is a comma separated list of pattern=LEVEL, ie. db/*=TRACE,http=WARN
+// a pattern is matched, as by path.Match, against the end of the package path of the caller, ie. db matches github.com/x/app/db,
+// and against the end of the caller's file name without .go, ie. db/* matches app/db/conn.go.
+// the first matching rule is used, an empty rules removes every override
+func SetLevelOverrides(rules string) error {
+	parsed, err := parseLevelOverrides(rules)
+	if err != nil {
+		return err
+	}
+	setLevelOverrides(parsed)
+	INFO.Printf("SetLevelOverrides(%+v)", rules)
+	return nil
+}
+
+// parseLevelOverrides parses the rules of SetLevelOverrides
+func parseLevelOverrides(rules string) ([]levelOverride, error) {
+	var parsed []levelOverride
+	for _, rule := range strings.Split(rules, ",") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		separator := strings.LastIndex(rule, "=")
+		if separator <= 0 {
+			return nil, fmt.Errorf("invalid level override %q, expected pattern=LEVEL", rule)
+		}
+		pattern := strings.TrimSpace(rule[:separator])
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid level override %q: %s", rule, err)
+		}
+		level, err := ParseLevel(rule[separator+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid level override %q: %s", rule, err)
+		}
+		parsed = append(parsed, levelOverride{pattern: pattern, level: level})
+	}
+	return parsed, nil
+}
+
+// setLevelOverrides replaces the rules, and forgets the levels callers resolved to
+func setLevelOverrides(rules []levelOverride) {
+	overrides.Lock()
+	overrides.rules = rules
+	overrides.cache = make(map[string]*Level)
+	overrides.generation++
+	overrides.Unlock()
+}
+
+// LevelOverrides returns the rules set with SetLevelOverrides, as pattern=LEVEL,...
+func LevelOverrides() string {
+	overrides.RLock()
+	defer overrides.RUnlock()
+	var rules []string
+	for _, rule := range overrides.rules {
+		rules = append(rules, rule.pattern+"="+LevelToString(rule.level))
+	}
+	return strings.Join(rules, ",")
+}
+
+// overrideLevel returns the threshold overriding the Outputs for a call from fileName in callerName, or nil when none match
+func overrideLevel(fileName string, callerName string) *Level {
+	overrides.RLock()
+	if len(overrides.rules) == 0 {
+		overrides.RUnlock()
+		return nil
+	}
+	key := fileName + "\x00" + callerName
+	level, cached := overrides.cache[key]
+	rules, generation := overrides.rules, overrides.generation
+	overrides.RUnlock()
+	if cached {
+		return level
+	}
+
+	packagePath := funcPackage(callerName)
+	filePath := strings.TrimSuffix(fileName, ".go")
+	for _, rule := range rules {
+		if matchPathSuffix(rule.pattern, packagePath) || matchPathSuffix(rule.pattern, filePath) {
+			ruleLevel := rule.level
+			level = &ruleLevel
+			break
+		}
+	}
+	overrides.Lock()
+	if overrides.cache != nil && overrides.generation == generation {
+		overrides.cache[key] = level
+	}
+	overrides.Unlock()
+	return level
+}
+
+// lowestOverride returns the lowest level of all of the overrides, or nil when there are none
+func lowestOverride() *Level {
+	overrides.RLock()
+	defer overrides.RUnlock()
+	var lowest *Level
+	for i, rule := range overrides.rules {
+		if lowest == nil || rule.level < *lowest {
+			lowest = &overrides.rules[i].level
+		}
+	}
+	return lowest
+}
+
+// matchPathSuffix is true when pattern matches name, or any of its trailing / separated parts
+func matchPathSuffix(pattern string, name string) bool {
+	if name == "" {
+		return false
+	}
+	for {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+		separator := strings.Index(name, "/")
+		if separator < 0 {
+			return false
+		}
+		name = name[separator+1:]
+	}
+}
+
+// funcPackage returns the package path of a function name from runtime, ie. github.com/x/app/db.(*Conn).Query
+func funcPackage(function string) string {
+	lastSlash := strings.LastIndex(function, "/")
+	if dot := strings.Index(function[lastSlash+1:], "."); dot >= 0 {
+		return function[:lastSlash+1+dot]
+	}
+	return function
+}
@@ -0,0 +1,51 @@
+package lgr
+
+import "fmt"
+import "time"
+
+import "github.com/fatih/color"
+
+// Record is a single log message, as it is handed to each of the Outputs
+// every Output renders it in its own way
+type Record struct {
+	Time       time.Time
+	Level      Level
+	Name       string					// Name of the LoggerT, ie. DEBUG
+	Message    string
+	Prefix     PrefixList
+	PrefixName bool
+	Fields     Fields
+	Flags      int
+	File       string					// File, Line and Function are from getCallerInformation
+	Line       int
+	Function   string
+	Stack      []StackFrame				// Stack is the stack of the goroutine, from the caller outwards, see SetStackLevel
+	color      *color.Color
+	printDebug bool
+	highlights Filters
+}
+
+// Field is a single key/value pair attached to a LoggerT with With
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Fields is the list of key/value pairs which are added to every Record of a LoggerT
+type Fields []Field
+
+// With returns a copy of fields with the key/value pairs in keyvals appended
+// keys which are not strings are formatted with fmt.Sprint
+// and a key without a value is given the value !MISSING
+func (fields Fields) With(keyvals ...interface{}) Fields {
+	derived := make(Fields, len(fields), len(fields)+len(keyvals)/2+1)
+	copy(derived, fields)
+	for i := 0; i < len(keyvals); i += 2 {
+		field := Field{Key: fmt.Sprint(keyvals[i]), Value: "!MISSING"}
+		if i+1 < len(keyvals) {
+			field.Value = keyvals[i+1]
+		}
+		derived = append(derived, field)
+	}
+	return derived
+}
@@ -0,0 +1,64 @@
+package lgr
+
+import "fmt"
+
+// repanic is whether Recover panics again once the panic is logged, guarded by settingsMu
+var repanic = true
+
+// SetRepanic chooses whether Recover, and so Go, panics again with the value once it has been logged,
+// true unless set, false swallows the panic and the goroutine carries on from the deferred Recover
+func SetRepanic(again bool) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	repanic = again
+}
+
+// Recover logs a panic, with its stack, at CRITICAL through every output of the default Instance,
+// flushes them, then panics again or returns, see SetRepanic
+// it must be deferred directly, ie. defer lgr.Recover()
+func Recover() {
+	if value := recover(); value != nil {
+		std.recovered(value)
+	}
+}
+
+// Recover is Recover for the outputs of this Instance, ie. defer inst.Recover()
+func (inst *Instance) Recover() {
+	if value := recover(); value != nil {
+		inst.recovered(value)
+	}
+}
+
+// Go runs f in a new goroutine, where a panic is logged by Recover
+func Go(f func()) {
+	std.Go(f)
+}
+
+// Go runs f in a new goroutine, where a panic is logged by the Recover of this Instance
+func (inst *Instance) Go(f func()) {
+	go func() {
+		defer inst.Recover()
+		f()
+	}()
+}
+
+// recovered logs value as a CRITICAL record with the stack of the panic, which is still in place while deferred,
+// it is written to every output whatever their thresholds and filters, so that a panic is never missing from the log
+func (inst *Instance) recovered(value interface{}) {
+	depth, filters := stackSettings()
+	fileName, lineNumber, callerName, stack, _ := getCallerStack(depth, filters)
+	record := inst.CRITICAL.newRecordAt(fmt.Sprintf("panic: %v", value), fileName, lineNumber, callerName)
+	record.Stack = stack
+	settingsMu.RLock()
+	outputs := inst.allOutputs()
+	again := repanic
+	settingsMu.RUnlock()
+	for _, output := range outputs {
+		output.WriteRecord(record)
+	}
+	runHooks(record)
+	inst.Flush()
+	if again {
+		panic(value)
+	}
+}
@@ -0,0 +1,76 @@
+package lgr
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Reopener is implemented by outputs backed by a file, which can be closed and opened again at the same path
+type Reopener interface {
+	Reopen() error
+}
+
+// Reopen closes and reopens the file of every output which has one, of every Instance, ie. after logrotate has moved it away
+// the first error is returned, but every output is tried
+func Reopen() (err error) {
+	settingsMu.RLock()
+	outputs := everyOutput()
+	settingsMu.RUnlock()
+	for _, output := range outputs {
+		reopener, ok := output.(Reopener)
+		if !ok {
+			continue
+		}
+		if reopenErr := reopener.Reopen(); reopenErr != nil && err == nil {
+			err = reopenErr
+		}
+	}
+	INFO.Printf("Reopen(%v)", err)
+	return err
+}
+
+// ReopenOnSignal calls Reopen whenever one of signals, SIGHUP when none are given, is received
+// stop ends this, errors are logged to ERROR
+func ReopenOnSignal(signals ...os.Signal) (stop func()) {
+	if len(signals) == 0 {
+		signals = []os.Signal{syscall.SIGHUP}
+	}
+	received := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(received, signals...)
+	go func() {
+		for {
+			select {
+				case <-received:
+					if err := Reopen(); err != nil {
+						ERROR.Printf("Failed to reopen log files: %s", err)
+					}
+				case <-done:
+					return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(received)
+		close(done)
+	}
+}
+
+// Reopen closes the log file and opens it again at the same path
+func (output *FileOutput) Reopen() error {
+	output.mu.Lock()
+	defer output.mu.Unlock()
+	if output.filePath == "" {
+		return nil
+	}
+	return output.openFile(output.filePath)
+}
+
+// Reopen reopens the wrapped Output, when it has a file
+func (async *AsyncOutput) Reopen() error {
+	if reopener, ok := async.output.(Reopener); ok {
+		return reopener.Reopen()
+	}
+	return nil
+}
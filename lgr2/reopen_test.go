@@ -0,0 +1,76 @@
+package lgr
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestReopenInstance checks that Reopen reaches the file of an Instance created by New, not only the default one
+func TestReopenInstance(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	file, err := (&FileOutput{}).SetLogFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	inst := New(file)
+	defer inst.Close()
+	inst.ERROR.Println("before")
+	// as logrotate does
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Reopen(); err != nil {
+		t.Fatal(err)
+	}
+	inst.ERROR.Println("after")
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "after") || strings.Contains(string(content), "before") {
+		t.Errorf("reopened log file holds %q, expected only the line after Reopen", content)
+	}
+}
+
+// TestInstanceClose checks Close forgets the Instance and closes its file, but not one another Instance shares
+func TestInstanceClose(t *testing.T) {
+	dir := t.TempDir()
+	own, err := (&FileOutput{}).SetLogFile(filepath.Join(dir, "own.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared, err := (&FileOutput{}).SetLogFile(filepath.Join(dir, "shared.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shared.Close()
+	inst := New(own, shared)
+	other := New(shared)
+	defer other.Close()
+	inst.Close()
+
+	settingsMu.RLock()
+	for _, registered := range instances {
+		if registered == inst {
+			t.Error("the closed Instance is still registered")
+		}
+	}
+	settingsMu.RUnlock()
+	own.mu.Lock()
+	closed := own.fileHandle == nil
+	own.mu.Unlock()
+	if !closed {
+		t.Error("the file of the closed Instance is still open")
+	}
+	other.ERROR.Println("still open")
+	content, err := os.ReadFile(filepath.Join(dir, "shared.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "still open") {
+		t.Errorf("shared log file holds %q", content)
+	}
+}
@@ -0,0 +1,136 @@
+//go:build go1.21
+// +build go1.21
+
+package lgr
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+)
+
+// slog levels for the lgr levels which slog has no name for,
+// ie. logger.Log(ctx, lgr.SlogLevelCritical, "msg")
+const (
+	SlogLevelTrace    slog.Level = -8
+	SlogLevelMsg      slog.Level = 2
+	SlogLevelCritical slog.Level = 12
+	SlogLevelFatal    slog.Level = 16
+)
+
+// SlogHandler is a slog.Handler which writes each slog.Record through the lgr logger of the matching level,
+// so that it follows the lgr thresholds, colors and Outputs
+// attributes become Fields, with groups joined to the key by a dot, ie. request.id
+// the ContextFields of the context passed to slog are added before them
+type SlogHandler struct {
+	fields   Fields
+	group    string			// group is the prefix, ie. "request.", for the keys of attributes
+	instance *Instance		// instance has the loggers written to
+}
+
+// NewSlogHandler returns a slog.Handler writing to the lgr loggers
+// use with slog.New(lgr.NewSlogHandler()) or slog.SetDefault
+func NewSlogHandler() *SlogHandler {
+	return std.SlogHandler()
+}
+
+// SlogHandler returns a slog.Handler writing to the loggers of this Instance
+func (inst *Instance) SlogHandler() *SlogHandler {
+	return &SlogHandler{instance: inst}
+}
+
+// slogLevel maps a slog level onto the lgr levels
+//  below Debug   -> TRACE
+//  Debug         -> DEBUG
+//  Info          -> INFO
+//  Info+2        -> MSG
+//  Warn          -> WARN
+//  Error         -> ERROR
+//  Error+4       -> CRITICAL
+//  Error+8 and up -> FATAL
+func slogLevel(level slog.Level) Level {
+	switch {
+		case level < slog.LevelDebug:
+			return LevelTrace
+		case level < slog.LevelInfo:
+			return LevelDebug
+		case level < SlogLevelMsg:
+			return LevelInfo
+		case level < slog.LevelWarn:
+			return LevelMsg
+		case level < slog.LevelError:
+			return LevelWarn
+		case level < SlogLevelCritical:
+			return LevelError
+		case level < SlogLevelFatal:
+			return LevelCritical
+		default:
+			return LevelFatal
+	}
+}
+
+// Enabled is true when an Output of the lgr logger for level would write the record
+func (handler *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return handler.instance.levelLogger(slogLevel(level)).enabled()
+}
+
+// Handle writes the slog.Record through the lgr logger of its level
+func (handler *SlogHandler) Handle(ctx context.Context, slogRecord slog.Record) error {
+	logger := handler.instance.levelLogger(slogLevel(slogRecord.Level))
+	var record *Record
+	if slogRecord.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{slogRecord.PC}).Next()
+		record = logger.newRecordAt(slogRecord.Message, frame.File, frame.Line, frame.Function)
+		_, _, _, record.Stack, _ = getCallerInformation(logger.Level)
+	} else {
+		record = logger.newRecord(slogRecord.Message)
+	}
+	if !slogRecord.Time.IsZero() {
+		record.Time = slogRecord.Time
+	}
+	fields := append(append(append(Fields{}, record.Fields...), ContextFields(ctx)...), handler.fields...)
+	slogRecord.Attrs(func(attr slog.Attr) bool {
+		fields = appendAttr(fields, handler.group, attr)
+		return true
+	})
+	record.Fields = fields
+	return logger.dispatch(record)
+}
+
+// WithAttrs returns a handler which adds attrs to every record
+func (handler *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	derived := *handler
+	derived.fields = append(Fields{}, handler.fields...)
+	for _, attr := range attrs {
+		derived.fields = appendAttr(derived.fields, handler.group, attr)
+	}
+	return &derived
+}
+
+// WithGroup returns a handler which places the keys of later attributes within the group name
+func (handler *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return handler
+	}
+	derived := *handler
+	derived.group = handler.group + name + "."
+	return &derived
+}
+
+// appendAttr appends attr to fields as a Field, group attributes are flattened into dotted keys
+func appendAttr(fields Fields, group string, attr slog.Attr) Fields {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return fields
+	}
+	if attr.Value.Kind() == slog.KindGroup {
+		if attr.Key != "" {
+			group += attr.Key + "."
+		}
+		for _, groupAttr := range attr.Value.Group() {
+			fields = appendAttr(fields, group, groupAttr)
+		}
+		return fields
+	}
+	return append(fields, Field{Key: group + attr.Key, Value: attr.Value.Any()})
+}
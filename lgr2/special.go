@@ -1,5 +1,6 @@
 package lgr
 
+import "fmt"
 import "time"
 import "strconv"
 import "runtime"
@@ -12,15 +13,14 @@ func buildHeader() ( header string ) {
 	header += Title
 	header += Created + "\n"
 	header += runtime.Compiler + "\n"
-	header += sys.GOOS + " - " + sys.GOARCH + "\n"
-	
+	header += runtime.GOOS + " - " + runtime.GOARCH + "\n"
+	return header
 }
 
 func getStatistics() ( stats string ) {
-	stats += sprintf("# CGO Calls:    %d \n",runtime.NumCgoCall())
-	stats += sprintf("# GO Routines:  %d \n",runtime.NumGoroutine())
-	stats += sprintf("# GO Routines:  %d \n",runtime.NumGoroutine())
-	stats += sprintf("# GO Routines:  %d \n",runtime.NumGoroutine())
-
-	
+	stats += fmt.Sprintf("# CGO Calls:    %d \n",runtime.NumCgoCall())
+	stats += fmt.Sprintf("# GO Routines:  %d \n",runtime.NumGoroutine())
+	stats += fmt.Sprintf("# GO Routines:  %d \n",runtime.NumGoroutine())
+	stats += fmt.Sprintf("# GO Routines:  %d \n",runtime.NumGoroutine())
+	return stats
 }
@@ -0,0 +1,83 @@
+package lgr
+
+import "path/filepath"
+import "strconv"
+import "strings"
+
+// the defaults of the stack traces captured with records, see SetStackLevel
+const (
+	defaultStackLevel = LevelError
+	defaultStackDepth = 32
+)
+
+var (
+	stackLevel		Level = defaultStackLevel		// stackLevel is where records start to carry a stack trace, guarded by settingsMu
+	stackDepth		int = defaultStackDepth			// stackDepth is the most frames in a stack trace, guarded by settingsMu
+	stackFilters	= []string{"runtime."}			// stackFilters are prefixes of functions left out of stack traces, guarded by settingsMu
+)
+
+// StackFrame is a single call within the Stack of a Record
+type StackFrame struct {
+	Function	string		`json:"func"`
+	File		string		`json:"file"`
+	Line		int			`json:"line"`
+}
+
+// SetStackLevel sets the level at and above which records carry the stack of the goroutine which logged them,
+// LevelError unless set, a level above LevelFatal turns stack traces off
+func SetStackLevel(level Level) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	stackLevel = level
+}
+
+// SetStackDepth sets the most frames a stack trace holds, 32 unless set
+func SetStackDepth(depth int) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	stackDepth = depth
+}
+
+// SetStackFilter leaves the frames of functions starting with any of prefixes, ie. "net/http.", out of stack traces
+// in place of the default, "runtime.", the frames of lgr itself are always left out
+func SetStackFilter(prefixes ...string) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	stackFilters = prefixes
+}
+
+// stackOptions returns the depth of the stack trace a record at level carries, 0 for none, and the filters to apply
+func stackOptions(level Level) (depth int, filters []string) {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+	if level < stackLevel {
+		return 0, nil
+	}
+	return stackDepth, stackFilters
+}
+
+// stackSettings returns the depth and filters of stack traces, whatever the level
+func stackSettings() (depth int, filters []string) {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+	return stackDepth, stackFilters
+}
+
+// isFilteredFrame is true when function starts with one of the filters
+func isFilteredFrame(function string, filters []string) bool {
+	for _, filter := range filters {
+		if strings.HasPrefix(function, filter) {
+			return true
+		}
+	}
+	return false
+}
+
+// stackText renders stack on a single line, each frame as function file.go:line, separated by sep
+func stackText(stack []StackFrame, sep string) string {
+	frames := make([]string, 0, len(stack))
+	for _, frame := range stack {
+		frames = append(frames, frame.Function+" "+filepath.Base(frame.File)+":"+strconv.Itoa(frame.Line))
+	}
+	return strings.Join(frames, sep)
+}
@@ -0,0 +1,116 @@
+package lgr
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+// stressLine is a whole console line of TestConcurrentLogging, with the ERROR prefix or one set by SetPrefix
+var stressLine = regexp.MustCompile(`^(ERROR: |P\d+ )\d{4}/\d\d/\d\d \d\d:\d\d:\d\d lgr_test\.go:\d+: stress \d+ \d+$`)
+
+// TestConcurrentLogging logs from many goroutines while the thresholds, prefixes and log file are changed,
+// run it with -race, every line must reach the console whole and the log files exactly once
+func TestConcurrentLogging(t *testing.T) {
+	const writers, lines = 8, 200
+	dir := t.TempDir()
+	paths := []string{filepath.Join(dir, "a.log"), filepath.Join(dir, "b.log")}
+
+	var console bytes.Buffer
+	savedOutput, savedNoColor := color.Output, color.NoColor
+	color.Output, color.NoColor = &console, true
+	savedLogThreshold, savedStdoutThreshold := LogThreshold(), StdoutThreshold()
+	defer func() {
+		DiscardLogging()
+		for _, n := range LogTypes {
+			n.SetPrefix(n.Name + ": ")
+		}
+		SetLogThreshold(savedLogThreshold)
+		SetStdoutThreshold(savedStdoutThreshold)
+		color.Output, color.NoColor = savedOutput, savedNoColor
+	}()
+	SetLogThreshold(LevelInfo)
+	if err := SetLogFile(paths[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	var changers sync.WaitGroup
+	change := func(f func(i int)) {
+		changers.Add(1)
+		go func() {
+			defer changers.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-done:
+					return
+				default:
+					f(i)
+				}
+			}
+		}()
+	}
+	// ERROR is above both thresholds, so every line reaches the console
+	change(func(i int) { SetStdoutThreshold([]Level{LevelMsg, LevelError}[i%2]) })
+	change(func(i int) { SetPrefix("P" + strconv.Itoa(i) + " ") })
+	change(func(i int) {
+		if err := SetLogFile(paths[i%2]); err != nil {
+			t.Error(err)
+		}
+	})
+	change(func(i int) {
+		if err := Reopen(); err != nil {
+			t.Error(err)
+		}
+	})
+
+	var loggers sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		loggers.Add(1)
+		go func(w int) {
+			defer loggers.Done()
+			for i := 0; i < lines; i++ {
+				ERROR.Println("stress", w, i)
+			}
+		}(w)
+	}
+	loggers.Wait()
+	close(done)
+	changers.Wait()
+
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSuffix(console.String(), "\n"), "\n") {
+		if !stressLine.MatchString(line) {
+			t.Errorf("console line is not whole: %q", line)
+			continue
+		}
+		message := line[strings.Index(line, "stress"):]
+		if seen[message] {
+			t.Errorf("console line written twice: %q", line)
+		}
+		seen[message] = true
+	}
+	if len(seen) != writers*lines {
+		t.Errorf("%d lines reached the console, expected %d", len(seen), writers*lines)
+	}
+
+	var logged int
+	for _, path := range paths {
+		// the loggers may be done before SetLogFile got to every path
+		content, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+		logged += strings.Count(string(content), ": stress ")
+	}
+	if logged != writers*lines {
+		t.Errorf("%d lines reached the log files, expected %d", logged, writers*lines)
+	}
+}